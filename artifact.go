@@ -0,0 +1,100 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// ArtifactOption sets which outputs to produce from a single page load. Style
+// settings of Pdf and Screenshot are used, while their BrowserOpts and RendererOpts
+// are ignored in favor of the ones of ArtifactOption.
+type ArtifactOption struct {
+	BrowserOpts  BrowserConf
+	RendererOpts RendererConf
+	// Produce html content of the page
+	HTML bool
+	// Produce visible text of the page
+	Text bool
+	// Produce MHTML archive of the page
+	MHTML bool
+	// Produce pdf of the page if set
+	Pdf *PdfOption
+	// Produce screenshot of the page if set
+	Screenshot *ScreenshotOption
+}
+
+func (opts ArtifactOption) readBrowserConf() BrowserConf {
+	return opts.BrowserOpts
+}
+
+func (opts ArtifactOption) readRendererConf() RendererConf {
+	return opts.RendererOpts
+}
+
+// Artifacts are the outputs produced from a single page load, output which is not
+// requested is nil.
+type Artifacts struct {
+	HTML       []byte
+	Text       []byte
+	MHTML      []byte
+	Pdf        []byte
+	Screenshot []byte
+}
+
+// validate checks if at least one output is requested and output settings are valid
+func (opts *ArtifactOption) validate() error {
+	if !opts.HTML && !opts.Text && !opts.MHTML && opts.Pdf == nil && opts.Screenshot == nil {
+		return fmt.Errorf("%w: no artifact requested", ErrInvalidOption)
+	}
+	if opts.Screenshot != nil {
+		return opts.Screenshot.validate()
+	}
+	return nil
+}
+
+// capture produces every requested output from the loaded page. Outputs which read
+// the document are captured before screenshot and pdf, as they may change the page
+// layout.
+func (opts *ArtifactOption) capture(ctx context.Context, artifacts *Artifacts) error {
+	if opts.HTML {
+		html, err := captureHTML(ctx)
+		if err != nil {
+			return fmt.Errorf("html: %w", err)
+		}
+		artifacts.HTML = html
+	}
+	if opts.Text {
+		var text string
+		if err := chromedp.Evaluate(`document.body ? document.body.innerText : ""`, &text).Do(ctx); err != nil {
+			return fmt.Errorf("text: %w", err)
+		}
+		artifacts.Text = []byte(text)
+	}
+	if opts.MHTML {
+		mhtml, err := page.CaptureSnapshot().WithFormat(page.CaptureSnapshotFormatMhtml).Do(ctx)
+		if err != nil {
+			return fmt.Errorf("mhtml: %w", err)
+		}
+		artifacts.MHTML = []byte(mhtml)
+	}
+	if opts.Screenshot != nil {
+		shot := *opts.Screenshot
+		shot.RendererOpts = opts.RendererOpts
+		buf, err := shot.capture(ctx)
+		if err != nil {
+			return fmt.Errorf("screenshot: %w", err)
+		}
+		artifacts.Screenshot = buf
+	}
+	if opts.Pdf != nil {
+		buf, _, err := opts.Pdf.setParams().Do(ctx)
+		if err != nil {
+			return fmt.Errorf("pdf: %w", err)
+		}
+		artifacts.Pdf = buf
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package renderer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestArtifactOptionValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  ArtifactOption
+		valid bool
+	}{
+		{"html", ArtifactOption{HTML: true}, true},
+		{"pdf", ArtifactOption{Pdf: &DefaultPdfOption}, true},
+		{"screenshot", ArtifactOption{Screenshot: &DefaultScreenshotOption}, true},
+		{"nothing requested", ArtifactOption{}, false},
+		{"invalid screenshot", ArtifactOption{Screenshot: &ScreenshotOption{Format: "gif"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if tt.valid && err != nil {
+				t.Errorf("validate() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidOption) {
+				t.Errorf("validate() = %v, want %v", err, ErrInvalidOption)
+			}
+		})
+	}
+}
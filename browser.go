@@ -0,0 +1,206 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// Browser is a long-lived automated browser shared by renders. Browser process is
+// started once and every render opens a new tab in an isolated browser context, so
+// launch flags from BrowserConf and RendererConf are only applied at start.
+type Browser struct {
+	browserConf  BrowserConf
+	rendererConf RendererConf
+
+	mu          sync.Mutex
+	allocCancel context.CancelFunc
+	ctx         context.Context // chromedp context of the browser process
+	cancel      context.CancelFunc
+	closed      bool
+}
+
+// NewBrowser create new browser instance with the given configuration. The browser
+// is started on first render, or by calling Start explicitly. Use WithBrowser to
+// render with the browser.
+func NewBrowser(browserConf BrowserConf, rendererConf RendererConf) *Browser {
+	return &Browser{
+		browserConf:  browserConf,
+		rendererConf: rendererConf,
+	}
+}
+
+// Start launches the browser process if it is not running yet. A browser process
+// which exited unexpectedly is launched again.
+func (b *Browser) Start() error {
+	return b.start(slog.Default())
+}
+
+func (b *Browser) start(logger *slog.Logger) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBrowserClosed
+	}
+	if b.ctx != nil && b.ctx.Err() == nil {
+		return nil
+	}
+	if b.cancel != nil {
+		// browser process exited, release resources before launching again
+		b.cancel()
+		b.allocCancel()
+	}
+
+	opts := RendererOption{BrowserOpts: b.browserConf, Opts: b.rendererConf}
+	// single process browser of container mode fails to open more tabs
+	allocCtx, allocCancel := newAllocator(context.Background(), opts, chromedp.Flag("single-process", false))
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if b.browserConf.ChromiumDebug {
+		ctx, cancel = chromedp.NewContext(allocCtx, chromedp.WithDebugf(logger.Debug))
+	} else {
+		ctx, cancel = chromedp.NewContext(allocCtx)
+	}
+
+	// first run allocates the browser process
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		allocCancel()
+		return fmt.Errorf("%w: %w", ErrBrowserLaunch, err)
+	}
+
+	b.allocCancel = allocCancel
+	b.ctx = ctx
+	b.cancel = cancel
+	return nil
+}
+
+// Close closes the browser process, renders in progress are cancelled.
+func (b *Browser) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	if b.ctx == nil {
+		return nil
+	}
+
+	err := chromedp.Cancel(b.ctx)
+	b.cancel()
+	b.allocCancel()
+	return err
+}
+
+// newTab opens a new tab in an isolated browser context for a single render with
+// its configuration. Browser context uses proxy of the render if it differs from the
+// proxy browser launched with. The tab is closed when ctx is done or the returned
+// cancel function is called.
+func (b *Browser) newTab(
+	ctx context.Context,
+	browserConf BrowserConf,
+	conf RendererConf,
+	logger *slog.Logger,
+) (context.Context, context.CancelFunc, error) {
+	if err := b.start(logger); err != nil {
+		return nil, nil, err
+	}
+
+	b.mu.Lock()
+	browserCtx := b.ctx
+	b.mu.Unlock()
+
+	proxy := b.browserConf
+	if browserConf.Proxy != "" {
+		proxy = browserConf
+	} else if b.browserConf.RemoteURL == "" {
+		// launched with the proxy
+		proxy = BrowserConf{}
+	}
+	tabCtx, cancel := chromedp.NewContext(browserCtx, chromedp.WithNewBrowserContext(withProxy(proxy)))
+	stop := context.AfterFunc(ctx, cancel)
+	cancelTab := func() {
+		stop()
+		cancel()
+	}
+
+	launched := &b.rendererConf
+	if b.browserConf.RemoteURL != "" {
+		// launch flags are not applied to remote browser
+		launched = nil
+	}
+	if err := chromedp.Run(tabCtx, setupTab(conf, launched)); err != nil {
+		cancelTab()
+		return nil, nil, err
+	}
+
+	return tabCtx, cancelTab, nil
+}
+
+func (b *Browser) defaultProxy() string {
+	return b.browserConf.Proxy
+}
+
+func (b *Browser) remote() bool {
+	return b.browserConf.RemoteURL != ""
+}
+
+// newAllocator returns chromedp allocator which connects to the remote browser if
+// RemoteURL is set, otherwise launches local browser with chrome options overridden
+// by extra options.
+func newAllocator(
+	ctx context.Context,
+	opts chromedpOption,
+	extra ...chromedp.ExecAllocatorOption,
+) (context.Context, context.CancelFunc) {
+	if remoteURL := opts.readBrowserConf().RemoteURL; remoteURL != "" {
+		return chromedp.NewRemoteAllocator(ctx, remoteURL)
+	}
+	return chromedp.NewExecAllocator(ctx, append(setChromeOpts(opts), extra...)...)
+}
+
+// tabRendererConf returns renderer config of a render in tab of remote browser or
+// the launched browser. Remote browser is not launched with ImageLoad, images are
+// blocked through interception instead.
+func tabRendererConf(conf RendererConf, remote bool) RendererConf {
+	if remote && !conf.ImageLoad && !slices.Contains(conf.BlockResourceTypes, network.ResourceTypeImage) {
+		conf.BlockResourceTypes = append(slices.Clone(conf.BlockResourceTypes), network.ResourceTypeImage)
+	}
+	return conf
+}
+
+// setupTab applies renderer configuration of a render which differs from launched,
+// the configuration the browser was launched with. Every setting is applied if
+// launched is nil.
+func setupTab(conf RendererConf, launched *RendererConf) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if conf.UserAgent != "" && (launched == nil || conf.UserAgent != launched.UserAgent) {
+			if err := emulation.SetUserAgentOverride(conf.UserAgent).Do(ctx); err != nil {
+				return err
+			}
+		}
+		if launched == nil ||
+			conf.WindowWidth != launched.WindowWidth ||
+			conf.WindowHeight != launched.WindowHeight {
+			err := emulation.SetDeviceMetricsOverride(
+				int64(conf.WindowWidth),
+				int64(conf.WindowHeight),
+				0,
+				false,
+			).Do(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
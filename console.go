@@ -0,0 +1,176 @@
+package renderer
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// maxConsoleEntries limits console messages and exceptions kept for a render, so a
+// page logging in a loop does not grow the result without bound
+const maxConsoleEntries = 1000
+
+// ConsoleMessage is a message logged by the page with console api
+type ConsoleMessage struct {
+	// Console api called, eg. log, info, warning, error, debug
+	Level string
+	Text  string
+	// Source location of the call, line and column are 1-based
+	URL    string
+	Line   int
+	Column int
+	Time   time.Time
+}
+
+// JSException is an uncaught javascript exception thrown by the page
+type JSException struct {
+	// Exception message, eg. Uncaught TypeError: x is not a function
+	Text string
+	// Source location of the exception, line and column are 1-based
+	URL    string
+	Line   int
+	Column int
+	Stack  []StackFrame
+	Time   time.Time
+}
+
+// StackFrame is a call frame of javascript stack trace, line and column are 1-based
+type StackFrame struct {
+	Function string
+	URL      string
+	Line     int
+	Column   int
+}
+
+// consoleRecord keeps console messages and uncaught exceptions of a render
+type consoleRecord struct {
+	mu         sync.Mutex
+	messages   []ConsoleMessage
+	exceptions []JSException
+}
+
+func newConsoleRecord() *consoleRecord {
+	return &consoleRecord{}
+}
+
+func (c *consoleRecord) addMessage(e *runtime.EventConsoleAPICalled) {
+	args := make([]string, 0, len(e.Args))
+	for _, arg := range e.Args {
+		args = append(args, remoteObjectText(arg))
+	}
+	msg := ConsoleMessage{
+		Level: e.Type.String(),
+		Text:  strings.Join(args, " "),
+	}
+	if e.Timestamp != nil {
+		msg.Time = e.Timestamp.Time()
+	}
+	if stack := stackFrames(e.StackTrace); len(stack) > 0 {
+		msg.URL, msg.Line, msg.Column = stack[0].URL, stack[0].Line, stack[0].Column
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.messages) < maxConsoleEntries {
+		c.messages = append(c.messages, msg)
+	}
+}
+
+func (c *consoleRecord) addException(e *runtime.EventExceptionThrown) {
+	details := e.ExceptionDetails
+	if details == nil {
+		return
+	}
+	exception := JSException{
+		Text:   details.Text,
+		URL:    details.URL,
+		Line:   int(details.LineNumber) + 1,
+		Column: int(details.ColumnNumber) + 1,
+		Stack:  stackFrames(details.StackTrace),
+	}
+	if details.Exception != nil {
+		// description of error object includes the stack, keep the message line only
+		message, _, _ := strings.Cut(remoteObjectText(details.Exception), "\n")
+		exception.Text = strings.TrimSpace(details.Text + " " + message)
+	}
+	if exception.URL == "" && len(exception.Stack) > 0 {
+		exception.URL = exception.Stack[0].URL
+	}
+	if e.Timestamp != nil {
+		exception.Time = e.Timestamp.Time()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.exceptions) < maxConsoleEntries {
+		c.exceptions = append(c.exceptions, exception)
+	}
+}
+
+// fill sets console messages and exceptions to the render result
+func (c *consoleRecord) fill(res *RenderResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res.Console = append([]ConsoleMessage(nil), c.messages...)
+	res.Exceptions = append([]JSException(nil), c.exceptions...)
+}
+
+// jsError returns JSError if the page threw uncaught exception or logged
+// console.error, nil otherwise
+func (c *consoleRecord) jsError() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	err := &JSError{Exceptions: append([]JSException(nil), c.exceptions...)}
+	for _, msg := range c.messages {
+		if msg.Level == runtime.APITypeError.String() {
+			err.Errors = append(err.Errors, msg)
+		}
+	}
+	if len(err.Exceptions) == 0 && len(err.Errors) == 0 {
+		return nil
+	}
+	return err
+}
+
+// remoteObjectText returns text of the remote object as shown in devtools console
+func remoteObjectText(o *runtime.RemoteObject) string {
+	switch {
+	case o.Type == runtime.TypeString:
+		var s string
+		if err := json.Unmarshal(o.Value, &s); err == nil {
+			return s
+		}
+	case o.UnserializableValue != "":
+		return o.UnserializableValue.String()
+	case o.Type == runtime.TypeUndefined:
+		return "undefined"
+	case len(o.Value) > 0:
+		return string(o.Value)
+	}
+	if o.Description != "" {
+		return o.Description
+	}
+	return o.Type.String()
+}
+
+// stackFrames converts call frames of devtools stack trace, async parent stacks are
+// not included
+func stackFrames(trace *runtime.StackTrace) []StackFrame {
+	if trace == nil {
+		return nil
+	}
+	frames := make([]StackFrame, 0, len(trace.CallFrames))
+	for _, frame := range trace.CallFrames {
+		frames = append(frames, StackFrame{
+			Function: frame.FunctionName,
+			URL:      frame.URL,
+			Line:     int(frame.LineNumber) + 1,
+			Column:   int(frame.ColumnNumber) + 1,
+		})
+	}
+	return frames
+}
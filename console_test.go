@@ -0,0 +1,107 @@
+package renderer
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/chromedp/cdproto/runtime"
+)
+
+func TestConsoleRecord(t *testing.T) {
+	stack := &runtime.StackTrace{CallFrames: []*runtime.CallFrame{
+		{FunctionName: "render", URL: "https://example.com/app.js", LineNumber: 9, ColumnNumber: 4},
+		{FunctionName: "", URL: "https://example.com/app.js", LineNumber: 20, ColumnNumber: 0},
+	}}
+
+	c := newConsoleRecord()
+	if err := c.jsError(); err != nil {
+		t.Errorf("jsError() of empty record = %v, want nil", err)
+	}
+
+	c.addMessage(&runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeLog,
+		Args: []*runtime.RemoteObject{
+			{Type: runtime.TypeString, Value: []byte(`"loaded \"items\""`)},
+			{Type: runtime.TypeNumber, Value: []byte(`3`)},
+			{Type: runtime.TypeNumber, UnserializableValue: "NaN"},
+			{Type: runtime.TypeUndefined},
+			{Type: runtime.TypeObject, Subtype: runtime.SubtypeNull, Value: []byte(`null`)},
+			{Type: runtime.TypeObject, ClassName: "Object", Description: "Object"},
+		},
+		StackTrace: stack,
+	})
+	if err := c.jsError(); err != nil {
+		t.Errorf("jsError() without errors = %v, want nil", err)
+	}
+
+	c.addMessage(&runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeError,
+		Args: []*runtime.RemoteObject{{Type: runtime.TypeString, Value: []byte(`"failed"`)}},
+	})
+	c.addException(&runtime.EventExceptionThrown{ExceptionDetails: &runtime.ExceptionDetails{
+		Text:         "Uncaught",
+		LineNumber:   9,
+		ColumnNumber: 4,
+		StackTrace:   stack,
+		Exception: &runtime.RemoteObject{
+			Type:        runtime.TypeObject,
+			Subtype:     runtime.SubtypeError,
+			Description: "TypeError: x is not a function\n    at render (https://example.com/app.js:10:5)",
+		},
+	}})
+	c.addException(&runtime.EventExceptionThrown{ExceptionDetails: &runtime.ExceptionDetails{
+		Text:      "Uncaught",
+		URL:       "https://example.com/inline.js",
+		Exception: &runtime.RemoteObject{Type: runtime.TypeString, Value: []byte(`"thrown string"`)},
+	}})
+
+	res := &RenderResult{}
+	c.fill(res)
+	wantConsole := []ConsoleMessage{
+		{
+			Level:  "log",
+			Text:   `loaded "items" 3 NaN undefined null Object`,
+			URL:    "https://example.com/app.js",
+			Line:   10,
+			Column: 5,
+		},
+		{Level: "error", Text: "failed"},
+	}
+	if !reflect.DeepEqual(res.Console, wantConsole) {
+		t.Errorf("Console = %+v, want %+v", res.Console, wantConsole)
+	}
+	wantExceptions := []JSException{
+		{
+			Text:   "Uncaught TypeError: x is not a function",
+			URL:    "https://example.com/app.js",
+			Line:   10,
+			Column: 5,
+			Stack: []StackFrame{
+				{Function: "render", URL: "https://example.com/app.js", Line: 10, Column: 5},
+				{URL: "https://example.com/app.js", Line: 21, Column: 1},
+			},
+		},
+		{Text: "Uncaught thrown string", URL: "https://example.com/inline.js", Line: 1, Column: 1, Stack: nil},
+	}
+	if !reflect.DeepEqual(res.Exceptions, wantExceptions) {
+		t.Errorf("Exceptions = %+v, want %+v", res.Exceptions, wantExceptions)
+	}
+
+	var jsErr *JSError
+	if err := c.jsError(); !errors.As(err, &jsErr) || len(jsErr.Exceptions) != 2 || len(jsErr.Errors) != 1 {
+		t.Errorf("jsError() = %v, want 2 exceptions and 1 console error", err)
+	}
+}
+
+func TestConsoleRecordLimit(t *testing.T) {
+	c := newConsoleRecord()
+	for range maxConsoleEntries + 10 {
+		c.addMessage(&runtime.EventConsoleAPICalled{Type: runtime.APITypeLog})
+	}
+	res := &RenderResult{}
+	c.fill(res)
+	if len(res.Console) != maxConsoleEntries {
+		t.Errorf("Console = %d messages, want %d", len(res.Console), maxConsoleEntries)
+	}
+}
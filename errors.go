@@ -0,0 +1,143 @@
+package renderer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrNavigation is returned when the browser failed to navigate to the url, use
+	// NavigationError with errors.As for the net error code.
+	ErrNavigation = errors.New("navigation failed")
+	// ErrWaitTimeout is returned when the page is not ready before timeout, use
+	// WaitTimeoutError with errors.As for the requests still in flight.
+	ErrWaitTimeout = errors.New("wait timeout")
+	// ErrBrowserLaunch is returned when the browser failed to launch or connect
+	ErrBrowserLaunch = errors.New("browser launch failed")
+	// ErrBrowserCrashed is returned when the browser exited or disconnected while
+	// rendering
+	ErrBrowserCrashed = errors.New("browser crashed")
+	// ErrInvalidOption is returned when the render option is invalid
+	ErrInvalidOption = errors.New("invalid option")
+	// ErrBrowserClosed is returned when rendering with a browser which is already closed
+	ErrBrowserClosed = errors.New("browser closed")
+	// ErrPoolBusy is returned when every tab of the browser pool is in use and the
+	// waiting queue is full.
+	ErrPoolBusy = errors.New("browser pool busy")
+	// ErrJavaScript is returned when FailOnJSError is set and the page threw uncaught
+	// exception or logged console.error, use JSError with errors.As for the details.
+	ErrJavaScript = errors.New("javascript error")
+	// ErrBadResponse is returned when the main document fails the response policy of
+	// RendererConf, use ResponseError with errors.As for the status and body.
+	ErrBadResponse = errors.New("bad response")
+)
+
+// NavigationError is the error of failed navigation, it matches ErrNavigation
+// with errors.Is.
+type NavigationError struct {
+	URL string
+	// Net error code reported by the browser, eg. net::ERR_NAME_NOT_RESOLVED
+	Code string
+}
+
+func (e *NavigationError) Error() string {
+	return fmt.Sprintf("navigate %s: %s", e.URL, e.Code)
+}
+
+func (e *NavigationError) Is(target error) bool {
+	return target == ErrNavigation
+}
+
+// WaitTimeoutError is the error of page not ready before timeout, it matches
+// ErrWaitTimeout with errors.Is.
+type WaitTimeoutError struct {
+	Timeout time.Duration
+	// Requests tracked by network idle check which are still in flight
+	Active []ActiveRequest
+	// Requests and idle timer events of network idle check until timeout
+	Idle *IdleReport
+	// Context error which ended the wait
+	Err error
+}
+
+// ActiveRequest is a request in flight when waiting for the page timed out
+type ActiveRequest struct {
+	URL          string
+	ResourceType string
+	Start        time.Time
+}
+
+func (e *WaitTimeoutError) Error() string {
+	return fmt.Sprintf(
+		"waitFor err: timeout after %v with %d active requests: %v",
+		e.Timeout,
+		len(e.Active),
+		e.Err,
+	)
+}
+
+func (e *WaitTimeoutError) Is(target error) bool {
+	return target == ErrWaitTimeout
+}
+
+func (e *WaitTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// JSError is the error of javascript errors of the rendered page, it matches
+// ErrJavaScript with errors.Is.
+type JSError struct {
+	// Uncaught exceptions thrown by the page
+	Exceptions []JSException
+	// Messages logged with console.error
+	Errors []ConsoleMessage
+}
+
+func (e *JSError) Error() string {
+	var first string
+	if len(e.Exceptions) > 0 {
+		first = e.Exceptions[0].Text
+	} else if len(e.Errors) > 0 {
+		first = e.Errors[0].Text
+	}
+	return fmt.Sprintf(
+		"javascript error: %d uncaught exceptions, %d console errors: %s",
+		len(e.Exceptions),
+		len(e.Errors),
+		first,
+	)
+}
+
+func (e *JSError) Is(target error) bool {
+	return target == ErrJavaScript
+}
+
+// ResponseError is the error of main document failing the response policy, it
+// matches ErrBadResponse with errors.Is.
+type ResponseError struct {
+	// Url of the page after redirects
+	URL string
+	// Http status code and content type of the main document response
+	StatusCode  int
+	ContentType string
+	// Policy failed, ResponseErrorStatus, ResponseErrorContentType or
+	// ResponseErrorHost
+	Reason string
+	// Response body of the main document
+	Body []byte
+}
+
+func (e *ResponseError) Error() string {
+	switch e.Reason {
+	case ResponseErrorHost:
+		return fmt.Sprintf("bad response %s: host not allowed", e.URL)
+	case ResponseErrorContentType:
+		return fmt.Sprintf("bad response %s: content type %s", e.URL, e.ContentType)
+	}
+	return fmt.Sprintf("bad response %s: status %d", e.URL, e.StatusCode)
+}
+
+func (e *ResponseError) Is(target error) bool {
+	return target == ErrBadResponse
+}
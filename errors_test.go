@@ -0,0 +1,100 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNavigationError(t *testing.T) {
+	err := fmt.Errorf("render: %w", &NavigationError{
+		URL:  "http://invalid.test",
+		Code: "net::ERR_NAME_NOT_RESOLVED",
+	})
+
+	if !errors.Is(err, ErrNavigation) {
+		t.Errorf("errors.Is(%v, ErrNavigation) = false", err)
+	}
+	var navErr *NavigationError
+	if !errors.As(err, &navErr) || navErr.Code != "net::ERR_NAME_NOT_RESOLVED" {
+		t.Errorf("errors.As(%v) code = %+v", err, navErr)
+	}
+}
+
+func TestWaitTimeoutError(t *testing.T) {
+	err := fmt.Errorf("render: %w", &WaitTimeoutError{
+		Timeout: 30 * time.Second,
+		Active:  []ActiveRequest{{URL: "http://example.com/poll", ResourceType: "XHR"}},
+		Err:     context.DeadlineExceeded,
+	})
+
+	if !errors.Is(err, ErrWaitTimeout) {
+		t.Errorf("errors.Is(%v, ErrWaitTimeout) = false", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("errors.Is(%v, context.DeadlineExceeded) = false", err)
+	}
+	var timeoutErr *WaitTimeoutError
+	if !errors.As(err, &timeoutErr) || len(timeoutErr.Active) != 1 {
+		t.Errorf("errors.As(%v) active = %+v", err, timeoutErr)
+	}
+}
+
+func TestRenderInvalidOption(t *testing.T) {
+	r := NewRenderer()
+
+	opts := DefaultRendererOption
+	opts.BrowserOpts.IdleType = "unknown"
+	if _, err := r.RenderPage("http://example.com", &opts); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("invalid idleType error = %v, want %v", err, ErrInvalidOption)
+	}
+
+	opts = DefaultRendererOption
+	opts.BrowserOpts.RemoteURL = "127.0.0.1:9222"
+	if _, err := r.RenderPage("http://example.com", &opts); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("invalid remoteURL error = %v, want %v", err, ErrInvalidOption)
+	}
+}
+
+func TestRenderBrowserLaunch(t *testing.T) {
+	r := NewRenderer()
+
+	opts := DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = "/nonexistent/chrome"
+	if _, err := r.RenderPage("http://example.com", &opts); !errors.Is(err, ErrBrowserLaunch) {
+		t.Errorf("launch error = %v, want %v", err, ErrBrowserLaunch)
+	}
+}
+
+func TestJSError(t *testing.T) {
+	err := fmt.Errorf("render: %w", &JSError{
+		Exceptions: []JSException{{Text: "Uncaught Error: boom"}},
+	})
+
+	if !errors.Is(err, ErrJavaScript) {
+		t.Errorf("errors.Is(%v, ErrJavaScript) = false", err)
+	}
+	var jsErr *JSError
+	if !errors.As(err, &jsErr) || len(jsErr.Exceptions) != 1 {
+		t.Errorf("errors.As(%v) = %+v", err, jsErr)
+	}
+}
+
+func TestResponseErrorIs(t *testing.T) {
+	err := fmt.Errorf("render: %w", &ResponseError{
+		URL:        "https://example.com/",
+		StatusCode: 500,
+		Reason:     ResponseErrorStatus,
+		Body:       []byte("internal error"),
+	})
+
+	if !errors.Is(err, ErrBadResponse) {
+		t.Errorf("errors.Is(%v, ErrBadResponse) = false", err)
+	}
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != 500 || string(respErr.Body) != "internal error" {
+		t.Errorf("errors.As(%v) = %+v", err, respErr)
+	}
+}
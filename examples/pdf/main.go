@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 
 	"github.com/liuminhaw/renderer"
 )
@@ -19,8 +20,35 @@ func main() {
 	marginLeft := flag.Float64("marginLeft", 1, "left margin in centimeter")
 	marginRight := flag.Float64("marginRight", 1, "right margin in centimeter")
 	idleType := flag.String("idleType", "auto",
-		"how to determine loading idle and return, valid input: auto, networkIdle, InteractiveTime")
+		"how to determine loading idle and return, valid input: auto, networkIdle, InteractiveTime, selector, expression, "+
+			"DOMContentLoaded, load, firstContentfulPaint, firstMeaningfulPaint, largestContentfulPaint, domQuiet")
+	waitSelector := flag.String("waitSelector", "", "css selector to wait for, only work with idleType=selector")
+	waitSelectorState := flag.String(
+		"waitSelectorState",
+		"present",
+		"state of waitSelector element to wait for, valid input: present, visible, hidden",
+	)
+	waitExpression := flag.String(
+		"waitExpression",
+		"",
+		"js expression to wait for being truthy, only work with idleType=expression",
+	)
+	domQuietWindow := flag.Duration(
+		"domQuietWindow",
+		500*time.Millisecond,
+		"time without dom mutation to consider page settled, only work with idleType=domQuiet",
+	)
+	domQuietFrames := flag.Int(
+		"domQuietFrames",
+		0,
+		"animation frames without dom mutation after domQuietWindow, only work with idleType=domQuiet",
+	)
 	browserExecPath := flag.String("browserPath", "", "manually set browser executable path")
+	remoteURL := flag.String(
+		"remoteURL",
+		"",
+		"devtools endpoint of running browser to connect to instead of launching browser",
+	)
 	container := flag.Bool(
 		"container",
 		false,
@@ -44,7 +72,12 @@ func main() {
 		os.Exit(1)
 	}
 	if !renderer.IsValidIdleType(*idleType) {
-		fmt.Println("Valid idleType value: networkIdle, InteractiveTime")
+		fmt.Println("Valid idleType value: auto, networkIdle, InteractiveTime, selector, expression, " +
+			"DOMContentLoaded, load, firstContentfulPaint, firstMeaningfulPaint, largestContentfulPaint, domQuiet")
+		os.Exit(1)
+	}
+	if *domQuietWindow < time.Duration(0) || *domQuietFrames < 0 {
+		fmt.Println("domQuietWindow / domQuietFrames value should be greater than or equal to 0")
 		os.Exit(1)
 	}
 	if len(flag.Args()) != 1 {
@@ -67,11 +100,17 @@ func main() {
 
 	context, err := r.RenderPdf(url, &renderer.PdfOption{
 		BrowserOpts: renderer.BrowserConf{
-			IdleType:        *idleType,
-			BrowserExecPath: *browserExecPath,
-			Container:       *container,
-			ChromiumDebug:   *chromiumDebug,
-			DebugMode:       *debug,
+			IdleType:          *idleType,
+			WaitSelector:      *waitSelector,
+			WaitSelectorState: *waitSelectorState,
+			WaitExpression:    *waitExpression,
+			DOMQuietWindow:    *domQuietWindow,
+			DOMQuietFrames:    *domQuietFrames,
+			BrowserExecPath:   *browserExecPath,
+			RemoteURL:         *remoteURL,
+			Container:         *container,
+			ChromiumDebug:     *chromiumDebug,
+			DebugMode:         *debug,
 		},
 		RendererOpts:        renderer.DefaultPdfOption.RendererOpts,
 		Landscape:           *landscape,
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/liuminhaw/renderer"
+)
+
+func main() {
+	browserWidth := flag.Int("bWidth", 1920, "width of browser window's size")
+	browserHeight := flag.Int("bHeight", 1080, "height of browser window's size")
+	timeout := flag.Int("timeout", 30, "seconds before timeout when rendering")
+	format := flag.String("format", "png", "image format, valid input: png, jpeg, webp")
+	quality := flag.Int("quality", 0, "compression quality from 0 to 100, only for jpeg and webp")
+	fullPage := flag.Bool("fullPage", false, "capture the whole page beyond window size")
+	selector := flag.String("selector", "", "capture the first element matching css selector")
+	scale := flag.Float64("scale", 0, "device scale factor")
+	idleType := flag.String("idleType", "auto",
+		"how to determine loading idle and return, valid input: auto, networkIdle, InteractiveTime, selector, expression, "+
+			"DOMContentLoaded, load, firstContentfulPaint, firstMeaningfulPaint, largestContentfulPaint, domQuiet")
+	waitSelector := flag.String("waitSelector", "", "css selector to wait for, only work with idleType=selector")
+	waitSelectorState := flag.String(
+		"waitSelectorState",
+		"present",
+		"state of waitSelector element to wait for, valid input: present, visible, hidden",
+	)
+	waitExpression := flag.String(
+		"waitExpression",
+		"",
+		"js expression to wait for being truthy, only work with idleType=expression",
+	)
+	domQuietWindow := flag.Duration(
+		"domQuietWindow",
+		500*time.Millisecond,
+		"time without dom mutation to consider page settled, only work with idleType=domQuiet",
+	)
+	domQuietFrames := flag.Int(
+		"domQuietFrames",
+		0,
+		"animation frames without dom mutation after domQuietWindow, only work with idleType=domQuiet",
+	)
+	browserExecPath := flag.String("browserPath", "", "manually set browser executable path")
+	remoteURL := flag.String(
+		"remoteURL",
+		"",
+		"devtools endpoint of running browser to connect to instead of launching browser",
+	)
+	container := flag.Bool(
+		"container",
+		false,
+		"indicate if running in container (docker / lambda) environment",
+	)
+	debug := flag.Bool("debug", false, "turn on for outputing debug message")
+	chromiumDebug := flag.Bool(
+		"chromiumDebug",
+		false,
+		"turn on for chromium debug message output (must enable debug for output)",
+	)
+
+	flag.Parse()
+
+	if *browserWidth <= 0 || *browserHeight <= 0 {
+		fmt.Println("Browser width / height value should be greater than 0")
+		os.Exit(1)
+	}
+	if !renderer.IsValidIdleType(*idleType) {
+		fmt.Println("Valid idleType value: auto, networkIdle, InteractiveTime, selector, expression, " +
+			"DOMContentLoaded, load, firstContentfulPaint, firstMeaningfulPaint, largestContentfulPaint, domQuiet")
+		os.Exit(1)
+	}
+	if *domQuietWindow < time.Duration(0) || *domQuietFrames < 0 {
+		fmt.Println("domQuietWindow / domQuietFrames value should be greater than or equal to 0")
+		os.Exit(1)
+	}
+	if len(flag.Args()) != 1 {
+		fmt.Printf("Usage: %s url\n", os.Args[0])
+		os.Exit(1)
+	}
+	url := flag.Arg(0)
+
+	var logger *slog.Logger
+	if *debug {
+		logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+			AddSource: true,
+			Level:     slog.LevelDebug,
+		}))
+	} else {
+		logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+	}
+
+	r := renderer.NewRenderer(renderer.WithLogger(logger))
+
+	rendererOpts := renderer.DefaultScreenshotOption.RendererOpts
+	rendererOpts.WindowWidth = *browserWidth
+	rendererOpts.WindowHeight = *browserHeight
+	rendererOpts.Timeout = *timeout
+
+	content, err := r.RenderScreenshot(url, &renderer.ScreenshotOption{
+		BrowserOpts: renderer.BrowserConf{
+			IdleType:          *idleType,
+			WaitSelector:      *waitSelector,
+			WaitSelectorState: *waitSelectorState,
+			WaitExpression:    *waitExpression,
+			DOMQuietWindow:    *domQuietWindow,
+			DOMQuietFrames:    *domQuietFrames,
+			BrowserExecPath:   *browserExecPath,
+			RemoteURL:         *remoteURL,
+			Container:         *container,
+			ChromiumDebug:     *chromiumDebug,
+			DebugMode:         *debug,
+		},
+		RendererOpts: rendererOpts,
+		Format:       *format,
+		Quality:      *quality,
+		FullPage:     *fullPage,
+		Selector:     *selector,
+		Scale:        *scale,
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("RenderScreenshot test: %s", err))
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll("result", 0775); err != nil {
+		logger.Error(fmt.Sprintf("RenderScreenshot test: %s", err))
+		os.Exit(1)
+	}
+	f, err := os.Create("result/result." + *format)
+	if err != nil {
+		logger.Error(fmt.Sprintf("RenderScreenshot test: %s", err))
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	f.Write(content)
+}
@@ -0,0 +1,536 @@
+package renderer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+// Request types of filter rule options, rule without type options matches every type
+// except document.
+const (
+	filterTypeScript uint32 = 1 << iota
+	filterTypeImage
+	filterTypeStylesheet
+	filterTypeXHR
+	filterTypeSubdocument
+	filterTypeDocument
+	filterTypeFont
+	filterTypeMedia
+	filterTypeWebSocket
+	filterTypePing
+	filterTypeOther
+
+	filterTypeDefault = ^filterTypeDocument
+)
+
+var filterTypeOptions = map[string]uint32{
+	"script":         filterTypeScript,
+	"image":          filterTypeImage,
+	"stylesheet":     filterTypeStylesheet,
+	"xmlhttprequest": filterTypeXHR,
+	"subdocument":    filterTypeSubdocument,
+	"document":       filterTypeDocument,
+	"font":           filterTypeFont,
+	"media":          filterTypeMedia,
+	"websocket":      filterTypeWebSocket,
+	"ping":           filterTypePing,
+	"other":          filterTypeOther,
+	"object":         filterTypeOther,
+}
+
+// cosmeticRule matches element hiding and snippet rules, which are not applied to
+// requests
+var cosmeticRule = regexp.MustCompile(`#[@?$%]*#`)
+
+// filterOption matches an option of a filter rule, eg. ~third-party or domain=a.com
+var filterOption = regexp.MustCompile(`^~?[a-z0-9-]+(=.*)?$`)
+
+// FilterList is a parsed Adblock Plus / EasyList filter list for blocking requests
+// while rendering. Request blocking rules with domain, third-party, match-case and
+// request type options are supported, as well as `@@` exceptions. Element hiding
+// rules and rules with other options are skipped. FilterList is safe for concurrent
+// use by multiple renders.
+type FilterList struct {
+	blocks     ruleIndex
+	exceptions ruleIndex
+	// number of request rules loaded
+	rules int
+}
+
+// ruleIndex looks up rules by a token of their pattern, so only rules which may
+// match the url are checked.
+type ruleIndex struct {
+	byToken map[string][]*filterRule
+	generic []*filterRule // rules without token
+}
+
+type filterRule struct {
+	text         string // original rule text, reported as the blocking rule
+	pattern      string // lower cased unless matchCase
+	re           *regexp.Regexp
+	domainAnchor bool // ||
+	startAnchor  bool // |
+	endAnchor    bool // trailing |
+	matchCase    bool
+	types        uint32
+	thirdParty   int // 1 third party only, -1 first party only, 0 both
+	domains      []string
+	notDomains   []string
+}
+
+// filterRequest is a request to match against filter rules
+type filterRequest struct {
+	url        string
+	lowerURL   string
+	pageHost   string
+	typ        uint32
+	thirdParty bool
+}
+
+// LoadFilterList loads Adblock Plus / EasyList filter files from disk into a single
+// FilterList.
+func LoadFilterList(paths ...string) (*FilterList, error) {
+	l := newFilterList()
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("load filter list: %w", err)
+		}
+		err = l.parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("load filter list %s: %w", path, err)
+		}
+	}
+	return l, nil
+}
+
+// ParseFilterList parses Adblock Plus / EasyList filter rules from r
+func ParseFilterList(r io.Reader) (*FilterList, error) {
+	l := newFilterList()
+	if err := l.parse(r); err != nil {
+		return nil, fmt.Errorf("parse filter list: %w", err)
+	}
+	return l, nil
+}
+
+func newFilterList() *FilterList {
+	return &FilterList{
+		blocks:     ruleIndex{byToken: make(map[string][]*filterRule)},
+		exceptions: ruleIndex{byToken: make(map[string][]*filterRule)},
+	}
+}
+
+// Len returns the number of request rules loaded
+func (l *FilterList) Len() int {
+	return l.rules
+}
+
+func (l *FilterList) parse(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "!") || strings.HasPrefix(line, "[") ||
+			cosmeticRule.MatchString(line) {
+			continue
+		}
+
+		exception := strings.HasPrefix(line, "@@")
+		rule, ok := parseFilterRule(line)
+		if !ok {
+			continue
+		}
+		if exception {
+			l.exceptions.add(rule)
+		} else {
+			l.blocks.add(rule)
+		}
+		l.rules++
+	}
+	return scanner.Err()
+}
+
+// parseFilterRule parses a request rule, ok is false if the rule is not supported
+func parseFilterRule(text string) (*filterRule, bool) {
+	rule := &filterRule{text: text, types: filterTypeDefault}
+	pattern := strings.TrimPrefix(text, "@@")
+
+	if i := strings.LastIndex(pattern, "$"); i >= 0 {
+		options := strings.Split(pattern[i+1:], ",")
+		valid := true
+		for _, option := range options {
+			if !filterOption.MatchString(option) {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			if !rule.parseOptions(options) {
+				return nil, false
+			}
+			pattern = pattern[:i]
+		}
+	}
+
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		expr := pattern[1 : len(pattern)-1]
+		if !rule.matchCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, false
+		}
+		rule.re = re
+		return rule, true
+	}
+
+	if strings.HasPrefix(pattern, "||") {
+		rule.domainAnchor = true
+		pattern = pattern[2:]
+	} else if strings.HasPrefix(pattern, "|") {
+		rule.startAnchor = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "|") {
+		rule.endAnchor = true
+		pattern = pattern[:len(pattern)-1]
+	}
+	if !rule.matchCase {
+		pattern = strings.ToLower(pattern)
+	}
+	rule.pattern = pattern
+
+	if strings.Trim(pattern, "*") == "" && rule.domains == nil && rule.types == filterTypeDefault &&
+		rule.thirdParty == 0 {
+		// matches every request
+		return nil, false
+	}
+	return rule, true
+}
+
+// parseOptions parses options of the rule, false if any option is not supported
+func (r *filterRule) parseOptions(options []string) bool {
+	var include, exclude uint32
+	for _, option := range options {
+		negate := strings.HasPrefix(option, "~")
+		name := strings.TrimPrefix(option, "~")
+
+		if typ, ok := filterTypeOptions[name]; ok {
+			if negate {
+				exclude |= typ
+			} else {
+				include |= typ
+			}
+			continue
+		}
+
+		switch {
+		case name == "third-party" || name == "3p":
+			r.thirdParty = 1
+			if negate {
+				r.thirdParty = -1
+			}
+		case name == "first-party" || name == "1p":
+			r.thirdParty = -1
+			if negate {
+				r.thirdParty = 1
+			}
+		case name == "match-case":
+			r.matchCase = true
+		case strings.HasPrefix(name, "domain=") && !negate:
+			for _, domain := range strings.Split(strings.TrimPrefix(name, "domain="), "|") {
+				if strings.HasPrefix(domain, "~") {
+					r.notDomains = append(r.notDomains, strings.TrimPrefix(domain, "~"))
+				} else if domain != "" {
+					r.domains = append(r.domains, domain)
+				}
+			}
+		default:
+			return false
+		}
+	}
+
+	if include != 0 {
+		r.types = include
+	}
+	r.types &^= exclude
+	return r.types != 0
+}
+
+// token returns the longest literal part of the pattern which is a whole token of
+// any url the rule matches, empty if there is none.
+func (r *filterRule) token() string {
+	if r.re != nil {
+		return ""
+	}
+	var best string
+	p := r.pattern
+	for start := 0; start < len(p); {
+		if !isTokenChar(p[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(p) && isTokenChar(p[end]) {
+			end++
+		}
+		boundedStart := start > 0 && p[start-1] != '*' ||
+			start == 0 && (r.domainAnchor || r.startAnchor)
+		boundedEnd := end < len(p) && p[end] != '*' || end == len(p) && r.endAnchor
+		if boundedStart && boundedEnd && end-start > len(best) {
+			best = strings.ToLower(p[start:end])
+		}
+		start = end
+	}
+	return best
+}
+
+func (r *filterRule) match(req filterRequest) bool {
+	if r.types&req.typ == 0 {
+		return false
+	}
+	if r.thirdParty == 1 && !req.thirdParty || r.thirdParty == -1 && req.thirdParty {
+		return false
+	}
+	if !r.matchDomain(req.pageHost) {
+		return false
+	}
+
+	target := req.lowerURL
+	if r.matchCase {
+		target = req.url
+	}
+	if r.re != nil {
+		return r.re.MatchString(target)
+	}
+
+	pattern := r.pattern
+	if !r.endAnchor {
+		pattern += "*"
+	}
+	switch {
+	case r.domainAnchor:
+		// pattern starts at the beginning of host or any of its labels
+		hostStart := strings.Index(target, "://")
+		if hostStart < 0 {
+			return false
+		}
+		hostStart += 3
+		hostEnd := hostStart + strings.IndexAny(target[hostStart:]+"/", "/:?#")
+		for i := hostStart; i < hostEnd; i++ {
+			if (i == hostStart || target[i-1] == '.') && globMatch(pattern, target[i:]) {
+				return true
+			}
+		}
+		return false
+	case r.startAnchor:
+		return globMatch(pattern, target)
+	default:
+		return globMatch("*"+pattern, target)
+	}
+}
+
+// matchDomain checks domain options against host of the page
+func (r *filterRule) matchDomain(pageHost string) bool {
+	for _, domain := range r.notDomains {
+		if isSubdomain(pageHost, domain) {
+			return false
+		}
+	}
+	if len(r.domains) == 0 {
+		return true
+	}
+	for _, domain := range r.domains {
+		if isSubdomain(pageHost, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (idx *ruleIndex) add(rule *filterRule) {
+	if token := rule.token(); token != "" {
+		idx.byToken[token] = append(idx.byToken[token], rule)
+		return
+	}
+	idx.generic = append(idx.generic, rule)
+}
+
+// find returns the first rule matching the request
+func (idx *ruleIndex) find(req filterRequest, tokens []string) *filterRule {
+	for _, token := range tokens {
+		for _, rule := range idx.byToken[token] {
+			if rule.match(req) {
+				return rule
+			}
+		}
+	}
+	for _, rule := range idx.generic {
+		if rule.match(req) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// match checks if the request of the page is blocked by the filter list, the
+// blocking rule is returned if blocked.
+func (l *FilterList) match(
+	rawURL, pageURL string,
+	resourceType network.ResourceType,
+	mainDocument bool,
+) (string, bool) {
+	host := urlHost(rawURL)
+	pageHost := urlHost(pageURL)
+	if pageHost == "" {
+		pageHost = host
+	}
+	req := filterRequest{
+		url:        rawURL,
+		lowerURL:   strings.ToLower(rawURL),
+		pageHost:   pageHost,
+		typ:        filterType(resourceType, mainDocument),
+		thirdParty: baseDomain(host) != baseDomain(pageHost),
+	}
+	tokens := urlTokens(req.lowerURL)
+
+	rule := l.blocks.find(req, tokens)
+	if rule == nil {
+		return "", false
+	}
+	if l.exceptions.find(req, tokens) != nil {
+		return "", false
+	}
+	if pageURL != "" && !mainDocument {
+		// page allowed by exception with document option
+		page := req
+		page.url, page.lowerURL = pageURL, strings.ToLower(pageURL)
+		page.typ = filterTypeDocument
+		page.thirdParty = false
+		if l.exceptions.find(page, urlTokens(page.lowerURL)) != nil {
+			return "", false
+		}
+	}
+	return rule.text, true
+}
+
+func filterType(resourceType network.ResourceType, mainDocument bool) uint32 {
+	switch resourceType {
+	case network.ResourceTypeDocument:
+		if mainDocument {
+			return filterTypeDocument
+		}
+		return filterTypeSubdocument
+	case network.ResourceTypeScript:
+		return filterTypeScript
+	case network.ResourceTypeImage:
+		return filterTypeImage
+	case network.ResourceTypeStylesheet:
+		return filterTypeStylesheet
+	case network.ResourceTypeXHR, network.ResourceTypeFetch:
+		return filterTypeXHR
+	case network.ResourceTypeFont:
+		return filterTypeFont
+	case network.ResourceTypeMedia:
+		return filterTypeMedia
+	case network.ResourceTypeWebSocket:
+		return filterTypeWebSocket
+	case network.ResourceTypePing:
+		return filterTypePing
+	default:
+		return filterTypeOther
+	}
+}
+
+// globMatch matches the whole s against pattern, where `*` matches any characters
+// and `^` matches a separator character or the end of s.
+func globMatch(pattern, s string) bool {
+	pi, si := 0, 0
+	starP, starS := -1, 0
+	for si < len(s) {
+		switch {
+		case pi < len(pattern) && pattern[pi] == '*':
+			starP, starS = pi, si
+			pi++
+		case pi < len(pattern) && (pattern[pi] == s[si] || pattern[pi] == '^' && isSeparator(s[si])):
+			pi++
+			si++
+		case starP >= 0:
+			starS++
+			pi, si = starP+1, starS
+		default:
+			return false
+		}
+	}
+	for pi < len(pattern) && (pattern[pi] == '*' || pattern[pi] == '^') {
+		pi++
+	}
+	return pi == len(pattern)
+}
+
+func isSeparator(c byte) bool {
+	return !isTokenChar(c) && c != '_' && c != '-' && c != '.'
+}
+
+func isTokenChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '%'
+}
+
+// urlTokens returns the distinct tokens of url
+func urlTokens(s string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	for start := 0; start < len(s); {
+		if !isTokenChar(s[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(s) && isTokenChar(s[end]) {
+			end++
+		}
+		if token := s[start:end]; !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+		start = end
+	}
+	return tokens
+}
+
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// isSubdomain checks if host is domain or its subdomain
+func isSubdomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// baseDomain approximates registrable domain of host for third-party check, it is the
+// last two labels, or three when the host ends with a short second level of a country
+// code, eg. example.co.uk.
+func baseDomain(host string) string {
+	labels := strings.Split(host, ".")
+	n := 2
+	if len(labels) > 2 && len(labels[len(labels)-1]) == 2 && len(labels[len(labels)-2]) <= 3 {
+		n = 3
+	}
+	if len(labels) <= n {
+		return host
+	}
+	return strings.Join(labels[len(labels)-n:], ".")
+}
@@ -0,0 +1,203 @@
+package renderer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestLoadFilterList(t *testing.T) {
+	l, err := LoadFilterList("testdata/filterlist.txt")
+	if err != nil {
+		t.Fatalf("LoadFilterList() = %v", err)
+	}
+	if l.Len() != 8 {
+		t.Errorf("Len() = %d, want 8", l.Len())
+	}
+
+	if _, err := LoadFilterList("testdata/missing.txt"); err == nil {
+		t.Error("LoadFilterList() with missing file = nil, want error")
+	}
+}
+
+func TestFilterListMatch(t *testing.T) {
+	l, err := LoadFilterList("testdata/filterlist.txt")
+	if err != nil {
+		t.Fatalf("LoadFilterList() = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		url          string
+		pageURL      string
+		resourceType network.ResourceType
+		rule         string
+	}{
+		{
+			"domain anchor",
+			"https://ads.example.net/banner.js",
+			"https://example.com/",
+			network.ResourceTypeScript,
+			"||ads.example.net^",
+		},
+		{
+			"domain anchor subdomain",
+			"https://eu.ads.example.net/banner.js",
+			"https://example.com/",
+			network.ResourceTypeScript,
+			"||ads.example.net^",
+		},
+		{
+			"domain anchor other domain",
+			"https://badads.example.net/banner.js",
+			"https://example.com/",
+			network.ResourceTypeScript,
+			"",
+		},
+		{
+			"exception",
+			"https://ads.example.net/allowed/ad.js",
+			"https://example.com/",
+			network.ResourceTypeScript,
+			"",
+		},
+		{
+			"end anchor",
+			"https://tracker.example.org/pixel.gif",
+			"https://example.com/",
+			network.ResourceTypeImage,
+			"||tracker.example.org/pixel.gif|",
+		},
+		{
+			"end anchor with query",
+			"https://tracker.example.org/pixel.gif?id=1",
+			"https://example.com/",
+			network.ResourceTypeImage,
+			"",
+		},
+		{
+			"wildcard and separator",
+			"https://example.com/banner/300x250/img?v=2",
+			"https://example.com/",
+			network.ResourceTypeImage,
+			"/banner/*/img^",
+		},
+		{
+			"separator not matched",
+			"https://example.com/banner/300x250/imgs",
+			"https://example.com/",
+			network.ResourceTypeImage,
+			"",
+		},
+		{
+			"third party",
+			"https://cdn.example.net/widget.js",
+			"https://example.com/",
+			network.ResourceTypeScript,
+			"||cdn.example.net/widget.js$script,third-party",
+		},
+		{
+			"first party",
+			"https://cdn.example.net/widget.js",
+			"https://www.example.net/",
+			network.ResourceTypeScript,
+			"",
+		},
+		{
+			"type not matched",
+			"https://cdn.example.net/widget.js",
+			"https://example.com/",
+			network.ResourceTypeXHR,
+			"",
+		},
+		{
+			"domain option",
+			"https://fonts.example.net/a.woff2",
+			"https://www.example.com/",
+			network.ResourceTypeFont,
+			"||fonts.example.net^$font,domain=example.com|~shop.example.com",
+		},
+		{
+			"domain option excluded",
+			"https://fonts.example.net/a.woff2",
+			"https://shop.example.com/",
+			network.ResourceTypeFont,
+			"",
+		},
+		{
+			"domain option not listed",
+			"https://fonts.example.net/a.woff2",
+			"https://example.org/",
+			network.ResourceTypeFont,
+			"",
+		},
+		{
+			"regex",
+			"https://example.com/static/ad300.js",
+			"https://example.com/",
+			network.ResourceTypeScript,
+			`/\/ad[0-9]+\.js$/$script`,
+		},
+		{
+			"document exception",
+			"https://ads.example.net/banner.js",
+			"https://www.example.edu/",
+			network.ResourceTypeScript,
+			"",
+		},
+		{
+			"unsupported option skipped",
+			"https://popup.example.net/",
+			"https://example.com/",
+			network.ResourceTypeScript,
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, blocked := l.match(tt.url, tt.pageURL, tt.resourceType, false)
+			if rule != tt.rule || blocked != (tt.rule != "") {
+				t.Errorf("match() = (%q, %v), want %q", rule, blocked, tt.rule)
+			}
+		})
+	}
+}
+
+func TestParseFilterListToken(t *testing.T) {
+	l, err := ParseFilterList(strings.NewReader("||ads.example.net^\nbanner\n*/ad_*.gif\n"))
+	if err != nil {
+		t.Fatalf("ParseFilterList() = %v", err)
+	}
+	// indexed by the longest bounded token
+	if len(l.blocks.byToken["example"]) != 1 || len(l.blocks.byToken["ad"]) != 1 {
+		t.Errorf("byToken = %v, want rules indexed by example and ad", l.blocks.byToken)
+	}
+	// unanchored token may be a part of a longer url token
+	if len(l.blocks.generic) != 1 {
+		t.Errorf("generic = %d rules, want 1", len(l.blocks.generic))
+	}
+	if rule, _ := l.match("https://example.com/topbanner.png", "", network.ResourceTypeImage, false); rule != "banner" {
+		t.Errorf("match() = %q, want %q", rule, "banner")
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		match   bool
+	}{
+		{"ads.example.net^*", "ads.example.net/a.js", true},
+		{"ads.example.net^*", "ads.example.net", true},
+		{"ads.example.net^*", "ads.example.network/a.js", false},
+		{"*/ad_*.gif*", "https://example.com/ad_top.gif", true},
+		{"*/ad_*.gif*", "https://example.com/pad_top.gif", false},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.match {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.match)
+		}
+	}
+}
@@ -0,0 +1,612 @@
+package renderer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+const (
+	harVersion = "1.2"
+	harPageID  = "page_1"
+	// harBodyTimeout limits time fetching a response body recorded in HAR
+	harBodyTimeout = 5 * time.Second
+)
+
+// HAR is a HTTP Archive 1.2 log of network activity of a render, see
+// http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Pages   []HARPage  `json:"pages"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HARPage struct {
+	StartedDateTime time.Time      `json:"startedDateTime"`
+	ID              string         `json:"id"`
+	Title           string         `json:"title"`
+	PageTimings     HARPageTimings `json:"pageTimings"`
+}
+
+// HARPageTimings are milliseconds since the page started, -1 if the event is not
+// fired
+type HARPageTimings struct {
+	OnContentLoad float64 `json:"onContentLoad"`
+	OnLoad        float64 `json:"onLoad"`
+}
+
+// HAREntry is a request and its response, Time is the total milliseconds of the
+// request
+type HAREntry struct {
+	Pageref         string      `json:"pageref,omitempty"`
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           HARCache    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARResponse is the response of a request, Status is 0 if the request failed or
+// no response is received
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type HARCookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitempty"`
+	Domain   string     `json:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	HTTPOnly bool       `json:"httpOnly,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is the response body, Text is recorded only if HARBodyLimit is set and
+// is base64 encoded if the body is not utf-8 text
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type HARCache struct{}
+
+// HARTimings are milliseconds of each phase of a request, -1 if the phase does not
+// apply. SSL is included in Connect.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// WriteFile writes the HAR as json to the named file
+func (h *HAR) WriteFile(name string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
+
+// LoadHAR loads HAR file from disk, eg. written by HAR.WriteFile
+func LoadHAR(name string) (*HAR, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("load har: %w", err)
+	}
+	defer f.Close()
+
+	har, err := ParseHAR(f)
+	if err != nil {
+		return nil, fmt.Errorf("load har %s: %w", name, err)
+	}
+	return har, nil
+}
+
+// ParseHAR parses HAR json from r
+func ParseHAR(r io.Reader) (*HAR, error) {
+	var har HAR
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return nil, fmt.Errorf("parse har: %w", err)
+	}
+	return &har, nil
+}
+
+// harRecorder records network events of a render into HAR entries, keyed by the
+// request ID of the events.
+type harRecorder struct {
+	bodyLimit int
+	logger    *slog.Logger
+
+	mu        sync.Mutex
+	entries   []*harRecord
+	active    map[network.RequestID]*harRecord // latest entry of the request ID
+	started   time.Time                        // local time of the first request
+	closed    bool                             // no body is fetched once built
+	bodyFetch sync.WaitGroup
+}
+
+// harRecord is a HAR entry being recorded with the timestamps to compute timings
+type harRecord struct {
+	entry    HAREntry
+	issued   float64 // monotonic seconds of request will be sent
+	ended    float64 // monotonic seconds of loading finished or failed
+	timing   *network.ResourceTiming
+	finished bool
+}
+
+func newHARRecorder(bodyLimit int, logger *slog.Logger) *harRecorder {
+	return &harRecorder{
+		bodyLimit: bodyLimit,
+		logger:    logger,
+		active:    make(map[network.RequestID]*harRecord),
+	}
+}
+
+// record records the network event, it is called from event listener. Nil recorder
+// records nothing.
+func (h *harRecorder) record(ctx context.Context, ev any) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch e := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		if rec, ok := h.active[e.RequestID]; ok && e.RedirectResponse != nil {
+			// redirect reuses the request ID, finish the redirected entry
+			rec.setResponse(e.RedirectResponse)
+			rec.finish(monotonicSeconds(e.Timestamp), e.RedirectResponse.EncodedDataLength)
+		}
+		if h.started.IsZero() {
+			h.started = time.Now()
+		}
+		rec := newHARRecord(e)
+		h.entries = append(h.entries, rec)
+		h.active[e.RequestID] = rec
+	case *network.EventResponseReceived:
+		if rec, ok := h.active[e.RequestID]; ok {
+			rec.setResponse(e.Response)
+		}
+	case *network.EventDataReceived:
+		if rec, ok := h.active[e.RequestID]; ok {
+			rec.entry.Response.Content.Size += e.DataLength
+		}
+	case *network.EventLoadingFinished:
+		rec, ok := h.active[e.RequestID]
+		if !ok || rec.finished {
+			return
+		}
+		rec.finish(monotonicSeconds(e.Timestamp), e.EncodedDataLength)
+		if h.bodyLimit > 0 && !h.closed {
+			if e.EncodedDataLength > float64(h.bodyLimit) {
+				rec.entry.Response.Content.Comment = "body exceeds HARBodyLimit"
+				return
+			}
+			h.bodyFetch.Add(1)
+			go h.fetchBody(ctx, e.RequestID, rec)
+		}
+	case *network.EventLoadingFailed:
+		rec, ok := h.active[e.RequestID]
+		if !ok || rec.finished {
+			return
+		}
+		rec.finish(monotonicSeconds(e.Timestamp), 0)
+		rec.entry.Comment = e.ErrorText
+	}
+}
+
+// fetchBody records response body of the request if it does not exceed the limit
+func (h *harRecorder) fetchBody(ctx context.Context, id network.RequestID, rec *harRecord) {
+	defer h.bodyFetch.Done()
+
+	c := chromedp.FromContext(ctx)
+	if c == nil || c.Target == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, harBodyTimeout)
+	defer cancel()
+	body, err := network.GetResponseBody(id).Do(cdp.WithExecutor(ctx, c.Target))
+	if err != nil {
+		h.logger.Debug(fmt.Sprintf("har response body %s: %s", id, err))
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	content := &rec.entry.Response.Content
+	content.Size = int64(len(body))
+	switch {
+	case len(body) > h.bodyLimit:
+		content.Comment = "body exceeds HARBodyLimit"
+	case utf8.Valid(body):
+		content.Text = string(body)
+	default:
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+}
+
+// build waits for response bodies being fetched and returns HAR of requests recorded
+// so far. Nil recorder returns nil.
+func (h *harRecorder) build(title string, events *lifecycle) *HAR {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	h.closed = true
+	h.mu.Unlock()
+	h.bodyFetch.Wait()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	har := &HAR{Log: HARLog{
+		Version: harVersion,
+		Creator: harCreator(),
+		Pages:   []HARPage{},
+		Entries: make([]HAREntry, 0, len(h.entries)),
+	}}
+	for _, rec := range h.entries {
+		har.Log.Entries = append(har.Log.Entries, rec.build())
+	}
+	if len(har.Log.Entries) > 0 {
+		har.Log.Pages = append(har.Log.Pages, HARPage{
+			StartedDateTime: har.Log.Entries[0].StartedDateTime,
+			ID:              harPageID,
+			Title:           title,
+			PageTimings: HARPageTimings{
+				OnContentLoad: sinceMillis(h.started, events.firedAt("DOMContentLoaded")),
+				OnLoad:        sinceMillis(h.started, events.firedAt("load")),
+			},
+		})
+	}
+	return har
+}
+
+func newHARRecord(e *network.EventRequestWillBeSent) *harRecord {
+	rec := &harRecord{issued: monotonicSeconds(e.Timestamp)}
+	if e.WallTime != nil {
+		rec.entry.StartedDateTime = e.WallTime.Time()
+	}
+	rec.entry.Pageref = harPageID
+
+	req := e.Request
+	headers := toHTTPHeader(req.Headers)
+	rec.entry.Request = HARRequest{
+		Method:      req.Method,
+		URL:         req.URL + req.URLFragment,
+		Cookies:     harRequestCookies(headers),
+		Headers:     harHeaders(req.Headers),
+		QueryString: harQueryString(req.URL),
+		HeadersSize: -1,
+	}
+	if req.HasPostData {
+		body := requestBody(req)
+		rec.entry.Request.PostData = &HARPostData{
+			MimeType: headers.Get("Content-Type"),
+			Text:     string(body),
+		}
+		rec.entry.Request.BodySize = int64(len(body))
+	}
+	rec.entry.Response = HARResponse{
+		Cookies:     []HARCookie{},
+		Headers:     []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	return rec
+}
+
+func (r *harRecord) setResponse(resp *network.Response) {
+	headers := toHTTPHeader(resp.Headers)
+	statusText := resp.StatusText
+	if statusText == "" {
+		// http/2 has no status text
+		statusText = http.StatusText(int(resp.Status))
+	}
+	version := harHTTPVersion(resp.Protocol)
+
+	r.entry.Request.HTTPVersion = version
+	if len(resp.RequestHeaders) > 0 {
+		// headers actually sent, including cookies
+		requestHeaders := toHTTPHeader(resp.RequestHeaders)
+		r.entry.Request.Headers = harHeaders(resp.RequestHeaders)
+		r.entry.Request.Cookies = harRequestCookies(requestHeaders)
+	}
+	r.entry.Response = HARResponse{
+		Status:      int(resp.Status),
+		StatusText:  statusText,
+		HTTPVersion: version,
+		Cookies:     harResponseCookies(headers),
+		Headers:     harHeaders(resp.Headers),
+		Content: HARContent{
+			Size:     r.entry.Response.Content.Size,
+			MimeType: resp.MimeType,
+		},
+		RedirectURL: headers.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+	r.entry.ServerIPAddress = strings.Trim(resp.RemoteIPAddress, "[]")
+	r.timing = resp.Timing
+}
+
+func (r *harRecord) finish(ended float64, encodedDataLength float64) {
+	r.finished = true
+	r.ended = ended
+	if r.entry.Response.Status != 0 {
+		r.entry.Response.BodySize = int64(encodedDataLength)
+	}
+}
+
+// build returns the HAR entry, request which is not finished yet is recorded up to
+// its response
+func (r *harRecord) build() HAREntry {
+	entry := r.entry
+	entry.Request.Cookies = nonNil(entry.Request.Cookies)
+	entry.Request.Headers = nonNil(entry.Request.Headers)
+	entry.Request.QueryString = nonNil(entry.Request.QueryString)
+	if !r.finished && entry.Comment == "" {
+		entry.Comment = "request not finished"
+	}
+
+	ended := r.ended
+	if !r.finished {
+		ended = r.issued
+		if r.timing != nil {
+			ended = r.timing.RequestTime + r.timing.ReceiveHeadersEnd/1000
+		}
+	}
+	entry.Timings, entry.Time = harTimings(r.issued, ended, r.timing)
+	return entry
+}
+
+// requestBody returns post data of the request, decoded from its entries
+func requestBody(req *network.Request) []byte {
+	var body []byte
+	for _, entry := range req.PostDataEntries {
+		data, err := base64.StdEncoding.DecodeString(entry.Bytes)
+		if err != nil {
+			continue
+		}
+		body = append(body, data...)
+	}
+	return body
+}
+
+// harTimings converts devtools resource timing to HAR timings, issued and ended are
+// monotonic seconds when the request is issued and finished. It returns the timings
+// and total milliseconds of the request.
+func harTimings(issued, ended float64, timing *network.ResourceTiming) (HARTimings, float64) {
+	total := max((ended-issued)*1000, 0)
+	if timing == nil {
+		// served without network, eg. from cache, data url or failed before sent
+		return HARTimings{DNS: -1, Connect: -1, SSL: -1, Wait: total}, total
+	}
+
+	t := HARTimings{DNS: -1, Connect: -1, SSL: -1}
+	t.Blocked = max((timing.RequestTime-issued)*1000, 0)
+	for _, start := range []float64{timing.DNSStart, timing.ConnectStart, timing.SendStart} {
+		if start >= 0 {
+			t.Blocked += start
+			break
+		}
+	}
+	if timing.DNSStart >= 0 {
+		t.DNS = timing.DNSEnd - timing.DNSStart
+	}
+	if timing.ConnectStart >= 0 {
+		t.Connect = timing.ConnectEnd - timing.ConnectStart
+	}
+	if timing.SslStart >= 0 {
+		t.SSL = timing.SslEnd - timing.SslStart
+	}
+	t.Send = max(timing.SendEnd-timing.SendStart, 0)
+	t.Wait = max(timing.ReceiveHeadersEnd-timing.SendEnd, 0)
+	t.Receive = max((ended-timing.RequestTime)*1000-timing.ReceiveHeadersEnd, 0)
+
+	total = t.Blocked + max(t.DNS, 0) + max(t.Connect, 0) + t.Send + t.Wait + t.Receive
+	return t, total
+}
+
+// harHeaders converts devtools headers to HAR headers sorted by name
+func harHeaders(headers network.Headers) []HARNameValue {
+	h := toHTTPHeader(headers)
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	nv := make([]HARNameValue, 0, len(h))
+	for _, name := range names {
+		for _, value := range h[name] {
+			nv = append(nv, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return nv
+}
+
+// harQueryString returns query parameters of the url in order
+func harQueryString(rawURL string) []HARNameValue {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return []HARNameValue{}
+	}
+
+	var params []HARNameValue
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		params = append(params, HARNameValue{Name: queryUnescape(name), Value: queryUnescape(value)})
+	}
+	return params
+}
+
+func queryUnescape(s string) string {
+	if unescaped, err := url.QueryUnescape(s); err == nil {
+		return unescaped
+	}
+	return s
+}
+
+func harRequestCookies(headers http.Header) []HARCookie {
+	var cookies []HARCookie
+	for _, line := range headers.Values("Cookie") {
+		parsed, err := http.ParseCookie(line)
+		if err != nil {
+			continue
+		}
+		for _, c := range parsed {
+			cookies = append(cookies, HARCookie{Name: c.Name, Value: c.Value})
+		}
+	}
+	return nonNil(cookies)
+}
+
+func harResponseCookies(headers http.Header) []HARCookie {
+	var cookies []HARCookie
+	for _, line := range headers.Values("Set-Cookie") {
+		c, err := http.ParseSetCookie(line)
+		if err != nil {
+			continue
+		}
+		cookie := HARCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			cookie.Expires = &c.Expires
+		}
+		cookies = append(cookies, cookie)
+	}
+	return nonNil(cookies)
+}
+
+// harHTTPVersion converts devtools protocol to HAR http version
+func harHTTPVersion(protocol string) string {
+	switch strings.ToLower(protocol) {
+	case "http/1.0":
+		return "HTTP/1.0"
+	case "http/1.1":
+		return "HTTP/1.1"
+	case "h2":
+		return "HTTP/2.0"
+	case "h3":
+		return "HTTP/3.0"
+	}
+	return protocol
+}
+
+// harCreator returns creator of the HAR with version of this module in the build
+func harCreator() HARCreator {
+	const path = "github.com/liuminhaw/renderer"
+	creator := HARCreator{Name: path, Version: "(devel)"}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return creator
+	}
+	for _, mod := range append([]*debug.Module{&info.Main}, info.Deps...) {
+		if mod.Path == path && mod.Version != "" {
+			creator.Version = mod.Version
+		}
+	}
+	return creator
+}
+
+// monotonicSeconds returns seconds of devtools monotonic time, 0 if t is nil
+func monotonicSeconds(t *cdp.MonotonicTime) float64 {
+	if t == nil {
+		return 0
+	}
+	return time.Time(*t).Sub(*cdp.MonotonicTimeEpoch).Seconds()
+}
+
+// sinceMillis returns milliseconds from start to t, -1 if either is zero
+func sinceMillis(start, t time.Time) float64 {
+	if start.IsZero() || t.IsZero() {
+		return -1
+	}
+	return float64(t.Sub(start)) / float64(time.Millisecond)
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
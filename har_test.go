@@ -0,0 +1,241 @@
+package renderer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// monotonic returns devtools monotonic time of seconds since the epoch
+func monotonic(seconds float64) *cdp.MonotonicTime {
+	t := cdp.MonotonicTime(cdp.MonotonicTimeEpoch.Add(time.Duration(seconds * float64(time.Second))))
+	return &t
+}
+
+func TestHARRecorder(t *testing.T) {
+	wall := cdp.TimeSinceEpoch(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	h := newHARRecorder(0, nil)
+	ctx := context.Background()
+
+	h.record(ctx, &network.EventRequestWillBeSent{
+		RequestID: "doc",
+		Request: &network.Request{
+			URL:         "http://example.com/old?a=1&b=x%20y",
+			URLFragment: "#top",
+			Method:      "GET",
+			Headers:     network.Headers{"Cookie": "sid=abc; theme=dark"},
+		},
+		Timestamp: monotonic(10),
+		WallTime:  &wall,
+	})
+	h.record(ctx, &network.EventRequestWillBeSent{
+		RequestID: "doc",
+		Request:   &network.Request{URL: "http://example.com/new", Method: "GET"},
+		Timestamp: monotonic(10.1),
+		WallTime:  &wall,
+		RedirectResponse: &network.Response{
+			Status:   301,
+			Headers:  network.Headers{"Location": "/new"},
+			Protocol: "http/1.1",
+		},
+	})
+	h.record(ctx, &network.EventResponseReceived{
+		RequestID: "doc",
+		Response: &network.Response{
+			Status:          200,
+			Headers:         network.Headers{"Content-Type": "text/html", "Set-Cookie": "seen=1; Path=/; HttpOnly"},
+			MimeType:        "text/html",
+			Protocol:        "h2",
+			RemoteIPAddress: "[::1]",
+		},
+	})
+	h.record(ctx, &network.EventDataReceived{RequestID: "doc", DataLength: 120})
+	h.record(ctx, &network.EventLoadingFinished{RequestID: "doc", Timestamp: monotonic(10.3), EncodedDataLength: 80})
+
+	postData := base64.StdEncoding.EncodeToString([]byte(`{"q":1}`))
+	h.record(ctx, &network.EventRequestWillBeSent{
+		RequestID: "api",
+		Request: &network.Request{
+			URL:             "http://example.com/api",
+			Method:          "POST",
+			Headers:         network.Headers{"Content-Type": "application/json"},
+			HasPostData:     true,
+			PostDataEntries: []*network.PostDataEntry{{Bytes: postData}},
+		},
+		Timestamp: monotonic(10.2),
+		WallTime:  &wall,
+	})
+	h.record(ctx, &network.EventLoadingFailed{RequestID: "api", Timestamp: monotonic(10.25), ErrorText: "net::ERR_BLOCKED_BY_CLIENT"})
+	h.record(ctx, &network.EventRequestWillBeSent{
+		RequestID: "poll",
+		Request:   &network.Request{URL: "http://example.com/poll", Method: "GET"},
+		Timestamp: monotonic(10.2),
+		WallTime:  &wall,
+	})
+
+	har := h.build("Example", newLifecycle())
+	if har.Log.Version != "1.2" || har.Log.Creator.Name == "" {
+		t.Errorf("log = %+v, want HAR 1.2 with creator", har.Log)
+	}
+	if len(har.Log.Pages) != 1 || har.Log.Pages[0].Title != "Example" || har.Log.Pages[0].PageTimings.OnLoad != -1 {
+		t.Errorf("pages = %+v, want page Example without load event", har.Log.Pages)
+	}
+	entries := har.Log.Entries
+	if len(entries) != 4 {
+		t.Fatalf("entries = %d, want 4", len(entries))
+	}
+
+	redirect := entries[0]
+	if redirect.Request.URL != "http://example.com/old?a=1&b=x%20y#top" ||
+		redirect.Response.Status != 301 ||
+		redirect.Response.RedirectURL != "/new" ||
+		redirect.Response.HTTPVersion != "HTTP/1.1" {
+		t.Errorf("redirect entry = %+v", redirect)
+	}
+	wantQuery := []HARNameValue{{Name: "a", Value: "1"}, {Name: "b", Value: "x y"}}
+	if !reflect.DeepEqual(redirect.Request.QueryString, wantQuery) {
+		t.Errorf("query string = %v, want %v", redirect.Request.QueryString, wantQuery)
+	}
+	wantCookies := []HARCookie{{Name: "sid", Value: "abc"}, {Name: "theme", Value: "dark"}}
+	if !reflect.DeepEqual(redirect.Request.Cookies, wantCookies) {
+		t.Errorf("request cookies = %v, want %v", redirect.Request.Cookies, wantCookies)
+	}
+	if redirect.Time < 99 || redirect.Time > 101 || !redirect.StartedDateTime.Equal(wall.Time()) {
+		t.Errorf("redirect time = %v started %v, want 100ms from %v", redirect.Time, redirect.StartedDateTime, wall.Time())
+	}
+
+	doc := entries[1]
+	if doc.Response.Status != 200 ||
+		doc.Response.StatusText != "OK" ||
+		doc.Response.HTTPVersion != "HTTP/2.0" ||
+		doc.Response.BodySize != 80 ||
+		doc.Response.Content.Size != 120 ||
+		doc.ServerIPAddress != "::1" {
+		t.Errorf("document entry = %+v", doc)
+	}
+	wantSetCookie := []HARCookie{{Name: "seen", Value: "1", Path: "/", HTTPOnly: true}}
+	if !reflect.DeepEqual(doc.Response.Cookies, wantSetCookie) {
+		t.Errorf("response cookies = %v, want %v", doc.Response.Cookies, wantSetCookie)
+	}
+
+	api := entries[2]
+	if api.Request.PostData == nil ||
+		api.Request.PostData.Text != `{"q":1}` ||
+		api.Request.PostData.MimeType != "application/json" ||
+		api.Response.Status != 0 ||
+		api.Comment != "net::ERR_BLOCKED_BY_CLIENT" {
+		t.Errorf("failed entry = %+v", api)
+	}
+	if poll := entries[3]; poll.Comment == "" || poll.Time != 0 {
+		t.Errorf("pending entry = %+v, want comment of unfinished request", poll)
+	}
+
+	// json arrays are required by HAR even if empty
+	data, err := json.Marshal(har)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw struct {
+		Log struct {
+			Entries []struct {
+				Response map[string]any `json:"response"`
+			} `json:"entries"`
+		} `json:"log"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatal(err)
+	}
+	if headers, ok := raw.Log.Entries[3].Response["headers"].([]any); !ok || headers == nil {
+		t.Errorf("pending response headers = %v, want empty array", raw.Log.Entries[3].Response["headers"])
+	}
+}
+
+func TestHARRecorderNil(t *testing.T) {
+	var h *harRecorder
+	h.record(context.Background(), &network.EventRequestWillBeSent{RequestID: "1"})
+	if har := h.build("", newLifecycle()); har != nil {
+		t.Errorf("build = %+v, want nil", har)
+	}
+}
+
+func TestHARTimings(t *testing.T) {
+	timing := &network.ResourceTiming{
+		RequestTime:       10.005,
+		DNSStart:          1,
+		DNSEnd:            3,
+		ConnectStart:      3,
+		ConnectEnd:        10,
+		SslStart:          5,
+		SslEnd:            10,
+		SendStart:         10,
+		SendEnd:           11,
+		ReceiveHeadersEnd: 31,
+	}
+	got, total := harTimings(10, 10.05, timing)
+	want := HARTimings{Blocked: 6, DNS: 2, Connect: 7, SSL: 5, Send: 1, Wait: 20, Receive: 14}
+	const epsilon = 1e-6
+	for name, pair := range map[string][2]float64{
+		"blocked": {got.Blocked, want.Blocked},
+		"dns":     {got.DNS, want.DNS},
+		"connect": {got.Connect, want.Connect},
+		"ssl":     {got.SSL, want.SSL},
+		"send":    {got.Send, want.Send},
+		"wait":    {got.Wait, want.Wait},
+		"receive": {got.Receive, want.Receive},
+		"total":   {total, 50},
+	} {
+		if d := pair[0] - pair[1]; d > epsilon || d < -epsilon {
+			t.Errorf("%s = %v, want %v", name, pair[0], pair[1])
+		}
+	}
+
+	// reused connection
+	got, _ = harTimings(10, 10.05, &network.ResourceTiming{
+		RequestTime: 10, DNSStart: -1, DNSEnd: -1, ConnectStart: -1, ConnectEnd: -1,
+		SslStart: -1, SslEnd: -1, SendStart: 2, SendEnd: 3, ReceiveHeadersEnd: 13,
+	})
+	if got.DNS != -1 || got.Connect != -1 || got.SSL != -1 || got.Blocked != 2 {
+		t.Errorf("timings of reused connection = %+v", got)
+	}
+
+	got, total = harTimings(10, 10.02, nil)
+	if got.Wait != total || got.Send != 0 || got.DNS != -1 {
+		t.Errorf("timings without resource timing = %+v, total %v", got, total)
+	}
+}
+
+func TestHARWriteFile(t *testing.T) {
+	har := &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: harCreator(),
+		Pages:   []HARPage{},
+		Entries: []HAREntry{{
+			Request:  HARRequest{Method: "GET", URL: "https://example.com/"},
+			Response: HARResponse{Status: 200, Content: HARContent{Text: "<html></html>"}},
+		}},
+	}}
+	name := filepath.Join(t.TempDir(), "render.har")
+	if err := har.WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got HAR
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal written HAR: %v", err)
+	}
+	if !reflect.DeepEqual(got.Log.Entries, har.Log.Entries) {
+		t.Errorf("entries = %+v, want %+v", got.Log.Entries, har.Log.Entries)
+	}
+}
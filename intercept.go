@@ -0,0 +1,325 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// pausedHandler decides how a request paused by Fetch interception goes on. It returns
+// an action which finishes the request, eg. fail or fulfill, or nil to pass the request
+// to the next handler. cont is the continue request params used when no handler
+// finishes the request, which can be modified by the handler.
+type pausedHandler func(e *fetch.EventRequestPaused, cont *fetch.ContinueRequestParams) chromedp.Action
+
+// interceptor handles requests paused by Fetch interception of a render with a
+// pipeline of handlers, requests are continued unchanged if no handler is set.
+type interceptor struct {
+	conf         RendererConf
+	handlers     []pausedHandler
+	targetOrigin string     // origin of the url to render
+	proxyAuth    *BasicAuth // credentials answering proxy auth challenge
+	replay       *replay    // serve requests from recorded HAR, nil if not replaying
+
+	mu        sync.Mutex
+	mainFrame cdp.FrameID // main frame of the render
+	pageURL   string      // url of the main document, for filter list domain options
+	blocked   []BlockedRequest
+	// block rule of requests by network request ID, "" if not blocked. Decided by
+	// the first of requestWillBeSent and requestPaused events of a request and
+	// taken by the other.
+	decisions    map[network.RequestID]string
+	unmatched    []string      // urls of requests not found in replayed HAR
+	deniedURL    string        // first main document url to host not allowed
+	denied       chan struct{} // closed when deniedURL is set
+	authAnswered map[fetch.RequestID]bool
+}
+
+// newInterceptor creates interceptor of the renderer config for rendering target url
+func newInterceptor(conf RendererConf, target string) *interceptor {
+	i := &interceptor{
+		conf:         conf,
+		targetOrigin: urlOrigin(target),
+		pageURL:      target,
+		authAnswered: make(map[fetch.RequestID]bool),
+		decisions:    make(map[network.RequestID]string),
+		denied:       make(chan struct{}),
+	}
+	if len(conf.AllowedHosts) > 0 {
+		i.handlers = append(i.handlers, i.hostHandler)
+	}
+	if i.blocking() {
+		i.handlers = append(i.handlers, i.blockHandler)
+	}
+	if len(conf.Mocks) > 0 {
+		i.handlers = append(i.handlers, i.mockHandler)
+	}
+	if conf.Replay != nil {
+		i.replay = newReplay(conf.Replay, conf.ReplayMatchBody)
+		i.handlers = append(i.handlers, i.replayHandler)
+	}
+	if len(conf.Headers) > 0 && conf.HeadersOriginOnly {
+		i.handlers = append(i.handlers, i.headerHandler)
+	}
+	return i
+}
+
+func (i *interceptor) enabled() bool {
+	return len(i.handlers) > 0 || i.conf.BasicAuth != nil || i.proxyAuth != nil
+}
+
+// blocking reports whether any block rule is set
+func (i *interceptor) blocking() bool {
+	return len(i.conf.BlockResourceTypes) > 0 || len(i.conf.BlockURLs) > 0 || len(i.conf.FilterLists) > 0
+}
+
+// setMainFrame sets main frame of the page being rendered
+func (i *interceptor) setMainFrame(frameID cdp.FrameID) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.mainFrame = frameID
+}
+
+// blockRule checks if the request is blocked and returns the blocking rule. Main
+// document request updates the page url used by following requests.
+func (i *interceptor) blockRule(
+	frameID cdp.FrameID,
+	resourceType network.ResourceType,
+	rawURL string,
+) (string, bool) {
+	i.mu.Lock()
+	mainDocument := resourceType == network.ResourceTypeDocument && frameID == i.mainFrame
+	if mainDocument {
+		i.pageURL = rawURL
+	}
+	pageURL := i.pageURL
+	i.mu.Unlock()
+
+	if slices.Contains(i.conf.BlockResourceTypes, resourceType) {
+		return "resourceType:" + resourceType.String(), true
+	}
+	for _, pattern := range i.conf.BlockURLs {
+		if pattern.Match(rawURL) {
+			return pattern.String(), true
+		}
+	}
+	for _, list := range i.conf.FilterLists {
+		if rule, ok := list.match(rawURL, pageURL, resourceType, mainDocument); ok {
+			return rule, true
+		}
+	}
+	return "", false
+}
+
+// blockDecision returns the block rule of the request of network request ID. Rule is
+// matched by the first event of the request and kept for the other event, so both
+// events agree and page url is updated once per main document request. Request
+// without ID is matched without keeping the decision.
+func (i *interceptor) blockDecision(
+	id network.RequestID,
+	frameID cdp.FrameID,
+	resourceType network.ResourceType,
+	rawURL string,
+) (string, bool) {
+	i.mu.Lock()
+	rule, decided := i.decisions[id]
+	if decided {
+		delete(i.decisions, id)
+	}
+	i.mu.Unlock()
+	if decided {
+		return rule, rule != ""
+	}
+
+	rule, blocked := i.blockRule(frameID, resourceType, rawURL)
+	if id != "" {
+		i.mu.Lock()
+		i.decisions[id] = rule
+		i.mu.Unlock()
+	}
+	return rule, blocked
+}
+
+// requestBlocked reports whether the request sent is failed by blockHandler, it is
+// called with requestWillBeSent event which may arrive before or after the request
+// is paused. Requests of other schemes, eg. data url, are never paused, so no
+// decision is kept for them.
+func (i *interceptor) requestBlocked(e *network.EventRequestWillBeSent) bool {
+	if !i.blocking() {
+		return false
+	}
+	if u, err := url.Parse(e.Request.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	_, blocked := i.blockDecision(e.RequestID, e.FrameID, e.Type, e.Request.URL)
+	return blocked
+}
+
+// blockedRequests returns a copy of requests blocked so far
+func (i *interceptor) blockedRequests() []BlockedRequest {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return slices.Clone(i.blocked)
+}
+
+// replayMisses returns a copy of urls of requests not found in replayed HAR so far
+func (i *interceptor) replayMisses() []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return slices.Clone(i.unmatched)
+}
+
+// enable returns action to enable Fetch interception if any handler is set
+func (i *interceptor) enable() chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if !i.enabled() {
+			return nil
+		}
+		return fetch.Enable().
+			WithPatterns([]*fetch.RequestPattern{
+				{URLPattern: "*", RequestStage: fetch.RequestStageRequest},
+			}).
+			WithHandleAuthRequests(i.conf.BasicAuth != nil || i.proxyAuth != nil).
+			Do(ctx)
+	})
+}
+
+// handle runs the request through handlers, it is called from event listener so the
+// request is finished in a new goroutine. It reports whether the request is failed by
+// a handler.
+func (i *interceptor) handle(ctx context.Context, e *fetch.EventRequestPaused, logger *slog.Logger) bool {
+	cont := fetch.ContinueRequest(e.RequestID)
+	var action chromedp.Action = cont
+	for _, handler := range i.handlers {
+		if a := handler(e, cont); a != nil {
+			action = a
+			break
+		}
+	}
+
+	i.run(ctx, action, e.Request.URL, logger)
+	_, failed := action.(*fetch.FailRequestParams)
+	return failed
+}
+
+// handleAuth answers auth challenge of the target origin with BasicAuth credentials,
+// and proxy auth challenge with proxy credentials. Challenge of the same request is
+// cancelled if the credentials are rejected.
+func (i *interceptor) handleAuth(ctx context.Context, e *fetch.EventAuthRequired, logger *slog.Logger) {
+	i.mu.Lock()
+	answered := i.authAnswered[e.RequestID]
+	i.authAnswered[e.RequestID] = true
+	i.mu.Unlock()
+
+	response := &fetch.AuthChallengeResponse{Response: fetch.AuthChallengeResponseResponseDefault}
+	switch {
+	case answered:
+		logger.Debug(fmt.Sprintf("auth rejected: %s", e.Request.URL))
+		response.Response = fetch.AuthChallengeResponseResponseCancelAuth
+	case e.AuthChallenge.Source == fetch.AuthChallengeSourceProxy && i.proxyAuth != nil:
+		response.Response = fetch.AuthChallengeResponseResponseProvideCredentials
+		response.Username = i.proxyAuth.Username
+		response.Password = i.proxyAuth.Password
+	case i.conf.BasicAuth != nil &&
+		e.AuthChallenge.Source != fetch.AuthChallengeSourceProxy &&
+		urlOrigin(e.AuthChallenge.Origin) == i.targetOrigin:
+		response.Response = fetch.AuthChallengeResponseResponseProvideCredentials
+		response.Username = i.conf.BasicAuth.Username
+		response.Password = i.conf.BasicAuth.Password
+	}
+	i.run(ctx, fetch.ContinueWithAuth(e.RequestID, response), e.Request.URL, logger)
+}
+
+// run runs the action finishing a paused request in a new goroutine, so the event
+// listener is not blocked.
+func (i *interceptor) run(ctx context.Context, action chromedp.Action, rawURL string, logger *slog.Logger) {
+	go func() {
+		c := chromedp.FromContext(ctx)
+		if c == nil || c.Target == nil {
+			return
+		}
+		if err := action.Do(cdp.WithExecutor(ctx, c.Target)); err != nil && ctx.Err() == nil {
+			logger.Debug(fmt.Sprintf("paused request %s: %s", rawURL, err))
+		}
+	}()
+}
+
+// blockHandler fails requests which are blocked and records them
+func (i *interceptor) blockHandler(
+	e *fetch.EventRequestPaused,
+	_ *fetch.ContinueRequestParams,
+) chromedp.Action {
+	rule, blocked := i.blockDecision(e.NetworkID, e.FrameID, e.ResourceType, e.Request.URL)
+	if !blocked {
+		return nil
+	}
+
+	i.mu.Lock()
+	i.blocked = append(i.blocked, BlockedRequest{
+		URL:          e.Request.URL,
+		ResourceType: e.ResourceType.String(),
+		Rule:         rule,
+	})
+	i.mu.Unlock()
+	return fetch.FailRequest(e.RequestID, network.ErrorReasonBlockedByClient)
+}
+
+// mockHandler fulfils or fails requests matching the first matched mock rule
+func (i *interceptor) mockHandler(
+	e *fetch.EventRequestPaused,
+	_ *fetch.ContinueRequestParams,
+) chromedp.Action {
+	for _, mock := range i.conf.Mocks {
+		if mock.match(e) {
+			return mock.action(e)
+		}
+	}
+	return nil
+}
+
+// headerHandler adds extra headers to requests of the target origin
+func (i *interceptor) headerHandler(
+	e *fetch.EventRequestPaused,
+	cont *fetch.ContinueRequestParams,
+) chromedp.Action {
+	if urlOrigin(e.Request.URL) != i.targetOrigin {
+		return nil
+	}
+
+	extra := make(map[string]bool, len(i.conf.Headers))
+	for name := range i.conf.Headers {
+		extra[http.CanonicalHeaderKey(name)] = true
+	}
+	headers := make([]*fetch.HeaderEntry, 0, len(e.Request.Headers)+len(i.conf.Headers))
+	for name, value := range e.Request.Headers {
+		if extra[http.CanonicalHeaderKey(name)] {
+			// replaced by extra header
+			continue
+		}
+		headers = append(headers, &fetch.HeaderEntry{Name: name, Value: fmt.Sprint(value)})
+	}
+	for name, value := range i.conf.Headers {
+		headers = append(headers, &fetch.HeaderEntry{Name: name, Value: value})
+	}
+	cont.Headers = headers
+	return nil
+}
+
+// urlOrigin returns scheme://host[:port] of the url, empty if url is invalid
+func urlOrigin(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host)
+}
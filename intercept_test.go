@@ -0,0 +1,185 @@
+package renderer
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+func TestInterceptorBlock(t *testing.T) {
+	if newInterceptor(RendererConf{}, "https://example.com/").enabled() {
+		t.Error("interceptor enabled without handlers")
+	}
+
+	i := newInterceptor(RendererConf{
+		BlockResourceTypes: []network.ResourceType{network.ResourceTypeFont},
+		BlockURLs:          []URLPattern{Glob("*analytics.example.com/*")},
+	}, "https://example.com/")
+	if !i.enabled() {
+		t.Fatal("interceptor not enabled with block rules")
+	}
+
+	tests := []struct {
+		resourceType network.ResourceType
+		url          string
+		blocked      bool
+	}{
+		{network.ResourceTypeFont, "https://example.com/font.woff2", true},
+		{network.ResourceTypeScript, "https://analytics.example.com/a.js", true},
+		{network.ResourceTypeScript, "https://example.com/app.js", false},
+	}
+	for _, tt := range tests {
+		for _, sentFirst := range []bool{false, true} {
+			e := &fetch.EventRequestPaused{
+				RequestID:    "paused",
+				NetworkID:    "request",
+				ResourceType: tt.resourceType,
+				Request:      &network.Request{URL: tt.url},
+			}
+			sent := &network.EventRequestWillBeSent{
+				RequestID: e.NetworkID,
+				Type:      tt.resourceType,
+				Request:   &network.Request{URL: tt.url},
+			}
+
+			// requestWillBeSent may arrive before or after the request is paused
+			var sentBlocked bool
+			if sentFirst {
+				sentBlocked = i.requestBlocked(sent)
+			}
+			action := i.handlers[0](e, fetch.ContinueRequest(e.RequestID))
+			if !sentFirst {
+				sentBlocked = i.requestBlocked(sent)
+			}
+			if sentBlocked != tt.blocked {
+				t.Errorf("requestBlocked(%s, %q, sent first %v) = %v, want %v",
+					tt.resourceType, tt.url, sentFirst, sentBlocked, tt.blocked)
+			}
+			if len(i.decisions) != 0 {
+				t.Errorf("decisions of %q kept after both events: %v", tt.url, i.decisions)
+			}
+			fail, ok := action.(*fetch.FailRequestParams)
+			if tt.blocked && (!ok || fail.ErrorReason != network.ErrorReasonBlockedByClient) {
+				t.Errorf("handler(%q) = %#v, want fail blocked by client", tt.url, action)
+			}
+			if !tt.blocked && action != nil {
+				t.Errorf("handler(%q) = %#v, want nil", tt.url, action)
+			}
+		}
+	}
+	if got := len(i.blockedRequests()); got != 4 {
+		t.Errorf("blockedRequests() = %d, want 4", got)
+	}
+
+	// data url is never paused, no decision is kept
+	dataURL := &network.EventRequestWillBeSent{
+		RequestID: "data",
+		Type:      network.ResourceTypeFont,
+		Request:   &network.Request{URL: "data:font/woff2;base64,AAAA"},
+	}
+	if i.requestBlocked(dataURL) || len(i.decisions) != 0 {
+		t.Errorf("data url blocked or decision kept: %v", i.decisions)
+	}
+}
+
+func TestInterceptorFilterList(t *testing.T) {
+	l, err := LoadFilterList("testdata/filterlist.txt")
+	if err != nil {
+		t.Fatalf("LoadFilterList() = %v", err)
+	}
+	i := newInterceptor(RendererConf{FilterLists: []*FilterList{l}}, "https://example.com/")
+	i.setMainFrame("main")
+
+	paused := func(frameID cdp.FrameID, resourceType network.ResourceType, url string) chromedp.Action {
+		e := &fetch.EventRequestPaused{
+			RequestID:    "paused",
+			FrameID:      frameID,
+			ResourceType: resourceType,
+			Request:      &network.Request{URL: url},
+		}
+		return i.blockHandler(e, fetch.ContinueRequest(e.RequestID))
+	}
+
+	// redirected main document decides domain options of following requests
+	if action := paused("main", network.ResourceTypeDocument, "https://www.example.edu/"); action != nil {
+		t.Errorf("main document blocked: %#v", action)
+	}
+	if action := paused("main", network.ResourceTypeScript, "https://ads.example.net/a.js"); action != nil {
+		t.Errorf("request of page allowed by exception blocked: %#v", action)
+	}
+
+	paused("main", network.ResourceTypeDocument, "https://example.com/")
+	if action := paused("main", network.ResourceTypeScript, "https://ads.example.net/a.js"); action == nil {
+		t.Error("request matching filter list not blocked")
+	}
+	want := []BlockedRequest{{
+		URL:          "https://ads.example.net/a.js",
+		ResourceType: network.ResourceTypeScript.String(),
+		Rule:         "||ads.example.net^",
+	}}
+	if got := i.blockedRequests(); !slices.Equal(got, want) {
+		t.Errorf("blockedRequests() = %+v, want %+v", got, want)
+	}
+}
+
+func TestInterceptorHeaders(t *testing.T) {
+	i := newInterceptor(RendererConf{
+		Headers:           map[string]string{"authorization": "Bearer token", "X-Env": "staging"},
+		HeadersOriginOnly: true,
+	}, "https://staging.example.com/app")
+	if !i.enabled() {
+		t.Fatal("interceptor not enabled with origin scoped headers")
+	}
+
+	paused := func(url string) *fetch.ContinueRequestParams {
+		e := &fetch.EventRequestPaused{
+			RequestID: "paused",
+			Request: &network.Request{
+				URL:     url,
+				Headers: network.Headers{"Authorization": "Basic old", "Accept": "text/html"},
+			},
+		}
+		cont := fetch.ContinueRequest(e.RequestID)
+		if action := i.headerHandler(e, cont); action != nil {
+			t.Errorf("headerHandler(%q) = %#v, want nil", url, action)
+		}
+		return cont
+	}
+
+	cont := paused("https://staging.example.com/api/data")
+	got := make(map[string]string)
+	for _, header := range cont.Headers {
+		got[header.Name] = header.Value
+	}
+	want := map[string]string{
+		"authorization": "Bearer token",
+		"X-Env":         "staging",
+		"Accept":        "text/html",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("headers = %v, want %v", got, want)
+	}
+
+	if cont := paused("https://cdn.example.com/app.js"); cont.Headers != nil {
+		t.Errorf("headers of other origin = %v, want unchanged", cont.Headers)
+	}
+}
+
+func TestURLOrigin(t *testing.T) {
+	tests := map[string]string{
+		"https://Example.com/path?q=1": "https://example.com",
+		"http://example.com:8080/":     "http://example.com:8080",
+		"https://example.com":          "https://example.com",
+		"not a url":                    "",
+	}
+	for rawURL, want := range tests {
+		if got := urlOrigin(rawURL); got != want {
+			t.Errorf("urlOrigin(%q) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package renderer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// MockRule fulfils requests matching URL and Method with a canned response, or fails
+// them with a net error, instead of sending them to the network.
+type MockRule struct {
+	// Requests with url matching the pattern are mocked
+	URL URLPattern
+	// Http method of requests to mock, every method if empty
+	Method string
+	// Status code of the response, 200 if not set
+	Status int
+	// Headers of the response, Content-Type is detected from body if not set
+	Headers map[string]string
+	// Body of the response
+	Body []byte
+	// File to read body of the response from, used if Body is nil
+	BodyFile string
+	// Net error to fail requests with instead of responding, eg.
+	// network.ErrorReasonConnectionRefused
+	Error network.ErrorReason
+	// Delay before responding or failing, eg. to simulate slow api
+	Delay time.Duration
+}
+
+func (m MockRule) match(e *fetch.EventRequestPaused) bool {
+	if m.Method != "" && !strings.EqualFold(m.Method, e.Request.Method) {
+		return false
+	}
+	return m.URL.Match(e.Request.URL)
+}
+
+// action returns action to fulfil or fail the paused request, body file is read when
+// the action is run so the event listener is not blocked.
+func (m MockRule) action(e *fetch.EventRequestPaused) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if m.Delay > 0 {
+			timer := time.NewTimer(m.Delay)
+			defer timer.Stop()
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if m.Error != "" {
+			return fetch.FailRequest(e.RequestID, m.Error).Do(ctx)
+		}
+		body := m.Body
+		if body == nil && m.BodyFile != "" {
+			var err error
+			if body, err = os.ReadFile(m.BodyFile); err != nil {
+				if err := fetch.FailRequest(e.RequestID, network.ErrorReasonFailed).Do(ctx); err != nil {
+					return err
+				}
+				return fmt.Errorf("mock body: %w", err)
+			}
+		}
+		return m.fulfill(e.RequestID, body).Do(ctx)
+	})
+}
+
+func (m MockRule) fulfill(id fetch.RequestID, body []byte) *fetch.FulfillRequestParams {
+	status := m.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	headers := make([]*fetch.HeaderEntry, 0, len(m.Headers)+1)
+	hasContentType := false
+	for name, value := range m.Headers {
+		hasContentType = hasContentType || strings.EqualFold(name, "Content-Type")
+		headers = append(headers, &fetch.HeaderEntry{Name: name, Value: value})
+	}
+	if !hasContentType && len(body) > 0 {
+		headers = append(headers, &fetch.HeaderEntry{
+			Name:  "Content-Type",
+			Value: http.DetectContentType(body),
+		})
+	}
+
+	params := fetch.FulfillRequest(id, int64(status)).WithResponseHeaders(headers)
+	if phrase := http.StatusText(status); phrase != "" {
+		params = params.WithResponsePhrase(phrase)
+	}
+	return params.WithBody(base64.StdEncoding.EncodeToString(body))
+}
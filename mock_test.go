@@ -0,0 +1,76 @@
+package renderer
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+)
+
+func pausedRequest(method, url string) *fetch.EventRequestPaused {
+	return &fetch.EventRequestPaused{
+		RequestID: "paused",
+		Request:   &network.Request{Method: method, URL: url},
+	}
+}
+
+func TestMockRuleMatch(t *testing.T) {
+	rule := MockRule{URL: Glob("https://api.example.com/*"), Method: http.MethodPost}
+
+	tests := []struct {
+		method string
+		url    string
+		match  bool
+	}{
+		{"POST", "https://api.example.com/items", true},
+		{"post", "https://api.example.com/items", true},
+		{"GET", "https://api.example.com/items", false},
+		{"POST", "https://example.com/items", false},
+	}
+	for _, tt := range tests {
+		if got := rule.match(pausedRequest(tt.method, tt.url)); got != tt.match {
+			t.Errorf("match(%s %s) = %v, want %v", tt.method, tt.url, got, tt.match)
+		}
+	}
+}
+
+func TestMockRuleFulfill(t *testing.T) {
+	params := MockRule{}.fulfill("paused", []byte("<html><body>mocked</body></html>"))
+	if params.ResponseCode != http.StatusOK || params.ResponsePhrase != "OK" {
+		t.Errorf("ResponseCode, ResponsePhrase = %d, %q", params.ResponseCode, params.ResponsePhrase)
+	}
+	if len(params.ResponseHeaders) != 1 || params.ResponseHeaders[0].Value != "text/html; charset=utf-8" {
+		t.Errorf("ResponseHeaders = %+v, want detected content type", params.ResponseHeaders)
+	}
+	if body, _ := base64.StdEncoding.DecodeString(params.Body); string(body) != "<html><body>mocked</body></html>" {
+		t.Errorf("Body = %q", body)
+	}
+
+	params = MockRule{
+		Status:  http.StatusServiceUnavailable,
+		Headers: map[string]string{"content-type": "application/json"},
+	}.fulfill("paused", []byte(`{}`))
+	if params.ResponseCode != http.StatusServiceUnavailable || len(params.ResponseHeaders) != 1 {
+		t.Errorf("params = %+v, want 503 with given content type only", params)
+	}
+}
+
+func TestInterceptorMock(t *testing.T) {
+	i := newInterceptor(RendererConf{Mocks: []MockRule{
+		{URL: Glob("*/flaky"), Error: network.ErrorReasonConnectionRefused},
+		{URL: Glob("https://api.example.com/*"), BodyFile: "testdata/mock.json"},
+	}}, "https://example.com/")
+	if !i.enabled() {
+		t.Fatal("interceptor not enabled with mock rules")
+	}
+
+	cont := fetch.ContinueRequest("paused")
+	if action := i.mockHandler(pausedRequest("GET", "https://api.example.com/flaky"), cont); action == nil {
+		t.Error("mockHandler() = nil for mocked request")
+	}
+	if action := i.mockHandler(pausedRequest("GET", "https://example.com/app.js"), cont); action != nil {
+		t.Errorf("mockHandler() = %#v for request not mocked, want nil", action)
+	}
+}
@@ -1,6 +1,7 @@
 package renderer
 
 import (
+	"slices"
 	"sync"
 	"time"
 
@@ -10,12 +11,145 @@ import (
 
 const longPollTimeout = 5 * time.Second
 
+// defaultIgnoredResourceTypes are not counted by network idle check unless
+// IgnoreResourceTypes is given
+var defaultIgnoredResourceTypes = []network.ResourceType{
+	network.ResourceTypeEventSource,
+	network.ResourceTypeWebSocket,
+	network.ResourceTypeMedia,
+	network.ResourceTypeTextTrack,
+	network.ResourceTypePing,
+	network.ResourceTypeManifest,
+}
+
+// IdleCheckOption can be provided to WithIdleCheck to decide which requests are
+// counted by network idle check
+type IdleCheckOption func(*idleFilter)
+
+// idleFilter decides which requests are counted by network idle check
+type idleFilter struct {
+	ignoreURLs    []URLPattern
+	includeURLs   []URLPattern
+	ignoredTypes  []network.ResourceType
+	longPoll      time.Duration // 0 never treats requests as long polling
+	mainFrameOnly bool
+}
+
+func newIdleFilter() idleFilter {
+	return idleFilter{
+		ignoredTypes: defaultIgnoredResourceTypes,
+		longPoll:     longPollTimeout,
+	}
+}
+
+// IgnoreURLs makes requests with url matching one of patterns not counted, eg.
+// analytics beacons or chat widgets.
+func IgnoreURLs(patterns ...URLPattern) IdleCheckOption {
+	return func(f *idleFilter) {
+		f.ignoreURLs = append(f.ignoreURLs, patterns...)
+	}
+}
+
+// IncludeURLs makes only requests with url matching one of patterns counted, main
+// document is always counted. IgnoreURLs is applied after IncludeURLs.
+func IncludeURLs(patterns ...URLPattern) IdleCheckOption {
+	return func(f *idleFilter) {
+		f.includeURLs = append(f.includeURLs, patterns...)
+	}
+}
+
+// IgnoreResourceTypes replaces the default ignored resource types (EventSource,
+// WebSocket, Media, TextTrack, Ping and Manifest) with types, call without types to
+// count requests of every resource type.
+func IgnoreResourceTypes(types ...network.ResourceType) IdleCheckOption {
+	return func(f *idleFilter) {
+		f.ignoredTypes = types
+	}
+}
+
+// LongPollThreshold sets how long an XHR / Fetch request stays in flight before it
+// is considered long polling and not counted, 5s by default. Long polling detection
+// is disabled if d is not positive.
+func LongPollThreshold(d time.Duration) IdleCheckOption {
+	return func(f *idleFilter) {
+		f.longPoll = max(d, 0)
+	}
+}
+
+// MainFrameOnly makes only requests of the main frame counted, requests of iframes
+// are counted by default.
+func MainFrameOnly() IdleCheckOption {
+	return func(f *idleFilter) {
+		f.mainFrameOnly = true
+	}
+}
+
+// ignored checks if the request is not counted by network idle check, main document
+// request is checked by its resource type only.
+func (f idleFilter) ignored(e *network.EventRequestWillBeSent, mainFrame cdp.FrameID) bool {
+	if slices.Contains(f.ignoredTypes, e.Type) {
+		return true
+	}
+	if e.Type == network.ResourceTypeDocument && e.FrameID == mainFrame {
+		return false
+	}
+	if f.mainFrameOnly && e.FrameID != mainFrame {
+		return true
+	}
+	if len(f.includeURLs) > 0 && !matchAny(f.includeURLs, e.Request.URL) {
+		return true
+	}
+	return matchAny(f.ignoreURLs, e.Request.URL)
+}
+
 type requestInfo struct {
 	start        time.Time
+	end          time.Time // zero if still in flight
 	resourceType network.ResourceType
 	url          string
 	// If ignore is true, the request will not be counted
-	ignore bool
+	ignore   bool
+	longPoll bool // ignored after promoted as long polling
+	filtered bool // ignored by idle filter
+}
+
+// Idle timer events recorded in IdleReport timeline
+const (
+	IdleTimerArm    = "arm"    // timer started with network quiet
+	IdleTimerReset  = "reset"  // running timer restarted by request activity
+	IdleTimerCancel = "cancel" // running timer stopped by too many active requests
+	IdleTimerBusy   = "busy"   // timer fired with too many active requests
+	IdleTimerIdle   = "idle"   // timer fired and network is idle
+)
+
+// IdleReport is the diagnostic of network idle check of a render, use it to tune
+// WithIdleCheck settings for a site.
+type IdleReport struct {
+	// Every request seen by network idle check in the order they are sent
+	Requests []IdleRequest
+	// Idle timer events in the order they happened
+	Timeline []IdleTimerEvent
+}
+
+// IdleRequest is a request seen by network idle check
+type IdleRequest struct {
+	URL          string
+	ResourceType string
+	Start        time.Time
+	// Zero if still in flight
+	End time.Time
+	// Promoted as long polling and not counted since then
+	LongPoll bool
+	// Not counted by the idle check filter, eg. noisy resource type or ignored url
+	Ignored bool
+}
+
+// IdleTimerEvent is an event of network idle timer
+type IdleTimerEvent struct {
+	Time  time.Time
+	Event string
+	// Requests counted as active when the event happened
+	Active int
 }
 
 type interactiveTime struct {
@@ -28,6 +162,15 @@ func newInteractiveTime() *interactiveTime {
 	}
 }
 
+// signal marks InteractiveTime as reached, extra signals are dropped so the
+// event listener never blocks.
+func (i *interactiveTime) signal() {
+	select {
+	case i.done <- struct{}{}:
+	default:
+	}
+}
+
 // networkIdle is a struct to keep track of requests state
 type networkIdle struct {
 	mu sync.Mutex
@@ -40,11 +183,16 @@ type networkIdle struct {
 	idleTimer   *time.Timer
 	idle        time.Duration
 	maxInflight int
+	filter      idleFilter
 	done        chan struct{}
 	stopped     bool
+	// every request seen and idle timer events for IdleReport
+	requests map[network.RequestID]*requestInfo
+	history  []*requestInfo
+	timeline []IdleTimerEvent
 }
 
-func newNetworkIdle(idle time.Duration, maxInflight int) *networkIdle {
+func newNetworkIdle(idle time.Duration, maxInflight int, filter idleFilter) *networkIdle {
 	return &networkIdle{
 		mu:          sync.Mutex{},
 		active:      make(map[network.RequestID]*requestInfo),
@@ -52,6 +200,8 @@ func newNetworkIdle(idle time.Duration, maxInflight int) *networkIdle {
 		done:        make(chan struct{}, 1),
 		idle:        idle,
 		maxInflight: maxInflight,
+		filter:      filter,
+		requests:    make(map[network.RequestID]*requestInfo),
 	}
 }
 
@@ -65,21 +215,70 @@ func (n *networkIdle) countActive() int {
 	return count
 }
 
+// activeRequests returns a copy of the requests which are still in flight
+func (n *networkIdle) activeRequests() []requestInfo {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	infos := make([]requestInfo, 0, len(n.active))
+	for _, info := range n.active {
+		infos = append(infos, *info)
+	}
+	return infos
+}
+
+// report returns a copy of the requests seen and idle timer events
+func (n *networkIdle) report() *IdleReport {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	report := &IdleReport{
+		Requests: make([]IdleRequest, 0, len(n.history)),
+		Timeline: slices.Clone(n.timeline),
+	}
+	for _, info := range n.history {
+		report.Requests = append(report.Requests, IdleRequest{
+			URL:          info.url,
+			ResourceType: info.resourceType.String(),
+			Start:        info.start,
+			End:          info.end,
+			LongPoll:     info.longPoll,
+			Ignored:      info.filtered,
+		})
+	}
+	return report
+}
+
+func (n *networkIdle) recordTimer(event string) {
+	n.timeline = append(n.timeline, IdleTimerEvent{
+		Time:   time.Now(),
+		Event:  event,
+		Active: n.countActive(),
+	})
+}
+
 func (n *networkIdle) startOrResetTimer() {
-	if n.idleTimer != nil {
-		if n.idleTimer.Stop() {
-			// stopped before firing; okay
-		}
+	if n.stopped {
+		return
 	}
+	event := IdleTimerArm
+	if n.idleTimer != nil && n.idleTimer.Stop() {
+		// stopped before firing
+		event = IdleTimerReset
+	}
+	n.recordTimer(event)
 	n.idleTimer = time.AfterFunc(n.idle, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
 		if n.stopped {
 			return
 		}
 		if n.countActive() <= n.maxInflight {
+			n.recordTimer(IdleTimerIdle)
 			select {
 			case n.done <- struct{}{}:
 			default:
 			}
+		} else {
+			n.recordTimer(IdleTimerBusy)
 		}
 	})
 }
@@ -87,15 +286,55 @@ func (n *networkIdle) startOrResetTimer() {
 func (n *networkIdle) maybeArm() {
 	if n.countActive() <= n.maxInflight {
 		n.startOrResetTimer()
-	} else if n.idleTimer != nil {
-		_ = n.idleTimer.Stop() // let it be re-armed when things quiet down
+	} else if n.idleTimer != nil && n.idleTimer.Stop() {
+		// let it be re-armed when things quiet down
+		n.recordTimer(IdleTimerCancel)
 	}
 }
 
+// track records the request for IdleReport, request of the same ID is ended since
+// redirect reuses the request ID.
+func (n *networkIdle) track(
+	id network.RequestID,
+	resourceType network.ResourceType,
+	url string,
+) *requestInfo {
+	now := time.Now()
+	n.end(id, now)
+	info := &requestInfo{
+		start:        now,
+		resourceType: resourceType,
+		url:          url,
+	}
+	n.requests[id] = info
+	n.history = append(n.history, info)
+	return info
+}
+
+// end records the end time of the request if it is not ended yet
+func (n *networkIdle) end(id network.RequestID, t time.Time) {
+	if info, ok := n.requests[id]; ok && info.end.IsZero() {
+		info.end = t
+	}
+}
+
+// ignoreRequest records the request ignored by idle filter, it is not counted
+func (n *networkIdle) ignoreRequest(
+	id network.RequestID,
+	resourceType network.ResourceType,
+	url string,
+) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	info := n.track(id, resourceType, url)
+	info.ignore = true
+	info.filtered = true
+}
+
 func (n *networkIdle) promoteLongPoll() {
 	n.mu.Lock()
 	defer n.mu.Unlock()
-	if n.stopped {
+	if n.stopped || n.filter.longPoll <= 0 {
 		return
 	}
 	now := time.Now()
@@ -103,8 +342,9 @@ func (n *networkIdle) promoteLongPoll() {
 		if (info.resourceType == network.ResourceTypeXHR ||
 			info.resourceType == network.ResourceTypeFetch) &&
 			!info.ignore &&
-			now.Sub(info.start) > longPollTimeout {
+			now.Sub(info.start) > n.filter.longPoll {
 			info.ignore = true // ignore this request
+			info.longPoll = true
 		}
 	}
 	n.maybeArm()
@@ -113,12 +353,7 @@ func (n *networkIdle) promoteLongPoll() {
 func (n *networkIdle) add(id network.RequestID, resourceType network.ResourceType, url string) int {
 	n.mu.Lock()
 	defer n.mu.Unlock()
-	n.active[id] = &requestInfo{
-		start:        time.Now(),
-		resourceType: resourceType,
-		url:          url,
-		ignore:       false,
-	}
+	n.active[id] = n.track(id, resourceType, url)
 	n.maybeArm()
 
 	return len(n.active)
@@ -127,12 +362,55 @@ func (n *networkIdle) add(id network.RequestID, resourceType network.ResourceTyp
 func (n *networkIdle) remove(id network.RequestID) int {
 	n.mu.Lock()
 	defer n.mu.Unlock()
+	n.end(id, time.Now())
 	delete(n.active, id)
 	n.maybeArm()
 
 	return len(n.active)
 }
 
+// untrack removes the request failed before loading, eg. by interceptor, if it is
+// active. Idle timer is left untouched for request not active.
+func (n *networkIdle) untrack(id network.RequestID) (activeLen int, ok bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.active[id]; !ok {
+		return len(n.active), false
+	}
+	n.end(id, time.Now())
+	delete(n.active, id)
+	n.maybeArm()
+
+	return len(n.active), true
+}
+
+// removeByLoader removes the document request recorded for the given loader ID.
+// ok is false if no request was recorded for the loader.
+func (n *networkIdle) removeByLoader(loaderID cdp.LoaderID) (activeLen int, ok bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	id, ok := n.byLoader[loaderID]
+	if !ok {
+		return len(n.active), false
+	}
+	n.end(id, time.Now())
+	delete(n.active, id)
+	delete(n.byLoader, loaderID)
+	n.maybeArm()
+
+	return len(n.active), true
+}
+
+// stop stops the idle timer and prevents further done signal
+func (n *networkIdle) stop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.stopped = true
+	if n.idleTimer != nil {
+		_ = n.idleTimer.Stop() // stop the timer if it is running
+	}
+}
+
 func (n *networkIdle) addByLoader(
 	loaderID cdp.LoaderID,
 	id network.RequestID,
@@ -141,30 +419,9 @@ func (n *networkIdle) addByLoader(
 ) int {
 	n.mu.Lock()
 	defer n.mu.Unlock()
-	n.active[id] = &requestInfo{
-		start:        time.Now(),
-		resourceType: resourceType,
-		url:          url,
-		ignore:       false,
-	}
+	n.active[id] = n.track(id, resourceType, url)
 	n.byLoader[loaderID] = id
 	n.maybeArm()
 
 	return len(n.active)
 }
-
-// isNoisyRequest checks if the request is considered noisy and should be ignored
-// based on its resource type.
-func isNoisyRequest(e *network.EventRequestWillBeSent) bool {
-	switch e.Type {
-	case network.ResourceTypeEventSource,
-		network.ResourceTypeWebSocket,
-		network.ResourceTypeMedia,
-		network.ResourceTypeTextTrack,
-		network.ResourceTypePing,
-		network.ResourceTypeManifest:
-		return true
-	}
-
-	return false
-}
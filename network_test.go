@@ -0,0 +1,297 @@
+package renderer
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestNewRenderStateIsolated(t *testing.T) {
+	r := NewRenderer(WithIdleCheck(50*time.Millisecond, 2))
+
+	a := r.newRenderState()
+	b := r.newRenderState()
+	if a.idleCheck == b.idleCheck || a.interactiveCheck == b.interactiveCheck {
+		t.Fatal("render states share wait trackers")
+	}
+	if a.idleCheck.idle != 50*time.Millisecond || a.idleCheck.maxInflight != 2 {
+		t.Errorf(
+			"idle check settings = (%v, %d), want (%v, %d)",
+			a.idleCheck.idle, a.idleCheck.maxInflight, 50*time.Millisecond, 2,
+		)
+	}
+
+	a.idleCheck.add("req-1", network.ResourceTypeScript, "https://example.com/a.js")
+	if len(b.idleCheck.active) != 0 {
+		t.Errorf("request tracked by another render state: %v", b.idleCheck.active)
+	}
+}
+
+func TestNetworkIdleConcurrentTrackers(t *testing.T) {
+	r := NewRenderer(WithIdleCheck(20*time.Millisecond, 0))
+
+	const renders = 8
+	const requests = 50
+
+	var wg sync.WaitGroup
+	states := make([]*renderState, renders)
+	for i := range states {
+		states[i] = r.newRenderState()
+	}
+
+	// Every render except the first one leaves a request in flight, so only the
+	// first render may ever become idle.
+	for i, state := range states {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < requests; j++ {
+				id := network.RequestID(fmt.Sprintf("%d-%d", i, j))
+				state.idleCheck.add(id, network.ResourceTypeFetch, "https://example.com")
+				state.idleCheck.remove(id)
+			}
+			state.idleCheck.addByLoader(
+				cdp.LoaderID("loader"),
+				"document",
+				network.ResourceTypeDocument,
+				"https://example.com",
+			)
+			if i == 0 {
+				state.idleCheck.removeByLoader(cdp.LoaderID("loader"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case <-states[0].idleCheck.done:
+	case <-time.After(time.Second):
+		t.Fatal("idle render never signaled done")
+	}
+	for i, state := range states[1:] {
+		select {
+		case <-state.idleCheck.done:
+			t.Errorf("render %d signaled done with a request in flight", i+1)
+		case <-time.After(100 * time.Millisecond):
+		}
+		state.idleCheck.stop()
+	}
+}
+
+func TestNetworkIdleStop(t *testing.T) {
+	n := newNetworkIdle(10*time.Millisecond, 0, newIdleFilter())
+
+	n.mu.Lock()
+	n.startOrResetTimer()
+	n.mu.Unlock()
+	n.stop()
+
+	select {
+	case <-n.done:
+		t.Fatal("stopped tracker signaled done")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestNetworkIdleUntrack(t *testing.T) {
+	n := newNetworkIdle(time.Hour, 0, newIdleFilter())
+	defer n.stop()
+
+	if _, ok := n.untrack("unknown"); ok || len(n.timeline) != 0 {
+		t.Errorf("untrack(unknown) = %v, timeline = %+v, want idle timer untouched", ok, n.timeline)
+	}
+	n.add("request", network.ResourceTypeScript, "https://example.com/app.js")
+	if activeLen, ok := n.untrack("request"); !ok || activeLen != 0 {
+		t.Errorf("untrack(request) = (%d, %v), want (0, true)", activeLen, ok)
+	}
+	if report := n.report(); len(report.Requests) != 1 || report.Requests[0].End.IsZero() {
+		t.Errorf("report = %+v, want untracked request ended", report.Requests)
+	}
+}
+
+func TestIdleFilterIgnored(t *testing.T) {
+	const mainFrame = cdp.FrameID("main")
+	request := func(resourceType network.ResourceType, frameID cdp.FrameID, url string) *network.EventRequestWillBeSent {
+		return &network.EventRequestWillBeSent{
+			Type:    resourceType,
+			FrameID: frameID,
+			Request: &network.Request{URL: url},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		opts    []IdleCheckOption
+		request *network.EventRequestWillBeSent
+		ignored bool
+	}{
+		{
+			"default counts script",
+			nil,
+			request(network.ResourceTypeScript, mainFrame, "https://example.com/app.js"),
+			false,
+		},
+		{
+			"default ignores websocket",
+			nil,
+			request(network.ResourceTypeWebSocket, mainFrame, "wss://example.com/ws"),
+			true,
+		},
+		{
+			"replaced resource types",
+			[]IdleCheckOption{IgnoreResourceTypes(network.ResourceTypeImage)},
+			request(network.ResourceTypeWebSocket, mainFrame, "wss://example.com/ws"),
+			false,
+		},
+		{
+			"ignore url",
+			[]IdleCheckOption{IgnoreURLs(Glob("*/collect*"))},
+			request(network.ResourceTypePing, mainFrame, "https://analytics.example.com/collect?v=1"),
+			true,
+		},
+		{
+			"include url not matched",
+			[]IdleCheckOption{IncludeURLs(Glob("https://example.com/*"))},
+			request(network.ResourceTypeScript, mainFrame, "https://widget.example.org/chat.js"),
+			true,
+		},
+		{
+			"include url matched but ignored",
+			[]IdleCheckOption{
+				IncludeURLs(Glob("https://example.com/*")),
+				IgnoreURLs(Glob("*/beacon")),
+			},
+			request(network.ResourceTypeFetch, mainFrame, "https://example.com/beacon"),
+			true,
+		},
+		{
+			"main document always counted",
+			[]IdleCheckOption{IncludeURLs(Glob("https://cdn.example.com/*"))},
+			request(network.ResourceTypeDocument, mainFrame, "https://example.com/"),
+			false,
+		},
+		{
+			"iframe counted by default",
+			nil,
+			request(network.ResourceTypeScript, "iframe", "https://example.com/frame.js"),
+			false,
+		},
+		{
+			"iframe ignored with main frame only",
+			[]IdleCheckOption{MainFrameOnly()},
+			request(network.ResourceTypeScript, "iframe", "https://example.com/frame.js"),
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRenderer(WithIdleCheck(defaultNetworkIdleWait, 0, tt.opts...))
+			if got := r.idleFilter.ignored(tt.request, mainFrame); got != tt.ignored {
+				t.Errorf("ignored() = %v, want %v", got, tt.ignored)
+			}
+		})
+	}
+}
+
+func TestNetworkIdleLongPollThreshold(t *testing.T) {
+	r := NewRenderer(WithIdleCheck(10*time.Millisecond, 0, LongPollThreshold(20*time.Millisecond)))
+	n := r.newRenderState().idleCheck
+	defer n.stop()
+
+	n.add("poll", network.ResourceTypeXHR, "https://example.com/poll")
+	n.add("script", network.ResourceTypeScript, "https://example.com/app.js")
+	time.Sleep(30 * time.Millisecond)
+	n.promoteLongPoll()
+
+	n.mu.Lock()
+	if !n.active["poll"].ignore || n.active["script"].ignore {
+		t.Errorf("ignore = (poll %v, script %v), want (true, false)",
+			n.active["poll"].ignore, n.active["script"].ignore)
+	}
+	n.mu.Unlock()
+
+	disabled := newIdleFilter()
+	LongPollThreshold(0)(&disabled)
+	n = newNetworkIdle(10*time.Millisecond, 0, disabled)
+	defer n.stop()
+	n.add("poll", network.ResourceTypeXHR, "https://example.com/poll")
+	n.active["poll"].start = time.Now().Add(-time.Hour)
+	n.promoteLongPoll()
+	if n.active["poll"].ignore {
+		t.Error("request promoted with long poll detection disabled")
+	}
+}
+
+func TestNetworkIdleReport(t *testing.T) {
+	n := newNetworkIdle(10*time.Millisecond, 0, newIdleFilter())
+
+	// armed before requests as NetworkIdle condition does
+	n.mu.Lock()
+	n.startOrResetTimer()
+	n.mu.Unlock()
+	n.add("redirect", network.ResourceTypeFetch, "https://example.com/old")
+	n.add("redirect", network.ResourceTypeFetch, "https://example.com/new")
+	n.ignoreRequest("ws", network.ResourceTypeWebSocket, "wss://example.com/ws")
+	n.add("poll", network.ResourceTypeXHR, "https://example.com/poll")
+	n.active["poll"].start = time.Now().Add(-time.Hour)
+	n.remove("redirect")
+	n.promoteLongPoll()
+
+	select {
+	case <-n.done:
+	case <-time.After(time.Second):
+		t.Fatal("tracker never signaled done")
+	}
+	n.stop()
+
+	report := n.report()
+	if len(report.Requests) != 4 {
+		t.Fatalf("Requests = %+v, want 4 requests", report.Requests)
+	}
+	old, redirected, ws, poll := report.Requests[0], report.Requests[1], report.Requests[2], report.Requests[3]
+	if old.End.IsZero() || redirected.End.IsZero() {
+		t.Errorf("redirect requests not ended: %+v, %+v", old, redirected)
+	}
+	if !ws.Ignored || ws.LongPoll {
+		t.Errorf("websocket request = %+v, want ignored", ws)
+	}
+	if !poll.LongPoll || poll.Ignored || !poll.End.IsZero() {
+		t.Errorf("poll request = %+v, want long poll in flight", poll)
+	}
+
+	events := make([]string, 0, len(report.Timeline))
+	for _, event := range report.Timeline {
+		events = append(events, event.Event)
+	}
+	if events[0] != IdleTimerArm || events[len(events)-1] != IdleTimerIdle {
+		t.Errorf("Timeline events = %v, want arm first and idle last", events)
+	}
+	if !slices.Contains(events, IdleTimerCancel) {
+		t.Errorf("Timeline events = %v, want cancel with requests in flight", events)
+	}
+}
+
+func TestInteractiveTimeSignal(t *testing.T) {
+	i := newInteractiveTime()
+
+	done := make(chan struct{})
+	go func() {
+		// repeated signals must not block the event listener
+		i.signal()
+		i.signal()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("signal blocked")
+	}
+	<-i.done
+}
@@ -0,0 +1,50 @@
+package renderer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// URLPattern matches request urls, created by Glob or Regexp
+type URLPattern struct {
+	re  *regexp.Regexp
+	src string
+}
+
+// Glob returns URLPattern matching the whole url against glob pattern, where `*`
+// matches any characters including `/`, eg. `*google-analytics.com/*`.
+func Glob(pattern string) URLPattern {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return URLPattern{
+		re:  regexp.MustCompile("^" + strings.Join(parts, ".*") + "$"),
+		src: pattern,
+	}
+}
+
+// Regexp returns URLPattern matching url against re, the pattern is unanchored
+// unless re is anchored.
+func Regexp(re *regexp.Regexp) URLPattern {
+	return URLPattern{re: re, src: re.String()}
+}
+
+// Match reports whether url matches the pattern, zero URLPattern matches nothing
+func (p URLPattern) Match(url string) bool {
+	return p.re != nil && p.re.MatchString(url)
+}
+
+func (p URLPattern) String() string {
+	return p.src
+}
+
+// matchAny reports whether url matches one of patterns
+func matchAny(patterns []URLPattern, url string) bool {
+	for _, pattern := range patterns {
+		if pattern.Match(url) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package renderer
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestURLPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern URLPattern
+		url     string
+		match   bool
+	}{
+		{Glob("*google-analytics.com/*"), "https://www.google-analytics.com/collect?v=1", true},
+		{Glob("*google-analytics.com/*"), "https://example.com/analytics.js", false},
+		{Glob("https://example.com/*.js"), "https://example.com/static/app.js", true},
+		{Glob("https://example.com/*.js"), "https://example.com/static/app.json", false},
+		{Glob("https://example.com/a+b"), "https://example.com/a+b", true},
+		{Glob("https://example.com/a+b"), "https://example.com/aab", false},
+		{Regexp(regexp.MustCompile(`/beacon\?`)), "https://example.com/beacon?id=1", true},
+		{Regexp(regexp.MustCompile(`^https://cdn\.`)), "https://example.com/cdn.js", false},
+		{URLPattern{}, "https://example.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.pattern.Match(tt.url); got != tt.match {
+			t.Errorf("%q.Match(%q) = %v, want %v", tt.pattern, tt.url, got, tt.match)
+		}
+	}
+}
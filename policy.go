@@ -0,0 +1,157 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// Reasons of ResponseError
+const (
+	ResponseErrorStatus      = "status"
+	ResponseErrorContentType = "contentType"
+	ResponseErrorHost        = "host"
+)
+
+// htmlContentTypes are content types of main document accepted by RequireHTML
+var htmlContentTypes = []string{"text/html", "application/xhtml+xml"}
+
+// StatusRange is an inclusive range of http status codes, eg. {Min: 500, Max: 599}
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// Contains reports whether status is in the range
+func (r StatusRange) Contains(status int) bool {
+	return status >= r.Min && status <= r.Max
+}
+
+// validateRendererConf checks if renderer config values are valid
+func validateRendererConf(conf RendererConf) error {
+	for _, r := range conf.FailStatus {
+		if r.Min > r.Max {
+			return fmt.Errorf("%w: invalid status range %d-%d", ErrInvalidOption, r.Min, r.Max)
+		}
+	}
+	for _, host := range conf.AllowedHosts {
+		if strings.TrimPrefix(host, "*.") == "" {
+			return fmt.Errorf("%w: invalid allowed host %q", ErrInvalidOption, host)
+		}
+	}
+	return nil
+}
+
+// checkResponse fails the render with ResponseError if the main document response or
+// the final url does not pass the response policy of the renderer config. Body of
+// the main document is fetched for the error.
+func checkResponse(conf RendererConf, state *renderState, finalURL *string) chromedp.ActionFunc {
+	return func(ctx context.Context) error {
+		requestID, resp := state.documents.response(state.frameID)
+		respErr := responseError(conf, *finalURL, resp)
+		if respErr == nil {
+			return nil
+		}
+		if requestID != "" {
+			if body, err := network.GetResponseBody(requestID).Do(ctx); err == nil {
+				respErr.Body = body
+			}
+		}
+		return respErr
+	}
+}
+
+// hostHandler fails main document requests to hosts not in AllowedHosts, including
+// redirects, and records the first denied url so the render fails without waiting
+func (i *interceptor) hostHandler(
+	e *fetch.EventRequestPaused,
+	_ *fetch.ContinueRequestParams,
+) chromedp.Action {
+	if e.ResourceType != network.ResourceTypeDocument || allowedHost(i.conf.AllowedHosts, e.Request.URL) {
+		return nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if e.FrameID != i.mainFrame {
+		return nil
+	}
+	if i.deniedURL == "" {
+		i.deniedURL = e.Request.URL
+		close(i.denied)
+	}
+	return fetch.FailRequest(e.RequestID, network.ErrorReasonBlockedByClient)
+}
+
+// hostDenied returns channel closed when a main document request is failed by
+// hostHandler
+func (i *interceptor) hostDenied() <-chan struct{} {
+	return i.denied
+}
+
+// hostError returns ResponseError of the main document request failed by hostHandler,
+// nil if none is failed
+func (i *interceptor) hostError() *ResponseError {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.deniedURL == "" {
+		return nil
+	}
+	return &ResponseError{URL: i.deniedURL, Reason: ResponseErrorHost}
+}
+
+// responseError returns ResponseError if the response or final url fails the
+// response policy, nil otherwise. Status and content type are not checked without
+// response, eg. page of data url.
+func responseError(conf RendererConf, finalURL string, resp *network.Response) *ResponseError {
+	respErr := &ResponseError{URL: finalURL}
+	if resp != nil {
+		respErr.StatusCode = int(resp.Status)
+		respErr.ContentType = resp.MimeType
+	}
+
+	switch {
+	case len(conf.AllowedHosts) > 0 && !allowedHost(conf.AllowedHosts, finalURL):
+		respErr.Reason = ResponseErrorHost
+	case resp == nil:
+		return nil
+	case slices.ContainsFunc(conf.FailStatus, func(r StatusRange) bool {
+		return r.Contains(int(resp.Status))
+	}):
+		respErr.Reason = ResponseErrorStatus
+	case conf.RequireHTML && !slices.Contains(htmlContentTypes, strings.ToLower(resp.MimeType)):
+		respErr.Reason = ResponseErrorContentType
+	default:
+		return nil
+	}
+	return respErr
+}
+
+// allowedHost checks if host of the url is one of hosts, `*.example.com` matches
+// subdomains of example.com
+func allowedHost(hosts []string, rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range hosts {
+		allowed = strings.ToLower(allowed)
+		if domain, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package renderer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestResponseError(t *testing.T) {
+	html := &network.Response{Status: 200, MimeType: "text/html"}
+	tests := []struct {
+		name     string
+		conf     RendererConf
+		finalURL string
+		resp     *network.Response
+		reason   string
+	}{
+		{"no policy", RendererConf{}, "https://example.com/", &network.Response{Status: 500}, ""},
+		{
+			"status in range",
+			RendererConf{FailStatus: []StatusRange{{Min: 400, Max: 499}, {Min: 500, Max: 599}}},
+			"https://example.com/",
+			&network.Response{Status: 503, MimeType: "text/html"},
+			ResponseErrorStatus,
+		},
+		{
+			"status not in range",
+			RendererConf{FailStatus: []StatusRange{{Min: 500, Max: 599}}},
+			"https://example.com/",
+			&network.Response{Status: 404, MimeType: "text/html"},
+			"",
+		},
+		{"html", RendererConf{RequireHTML: true}, "https://example.com/", html, ""},
+		{
+			"xhtml",
+			RendererConf{RequireHTML: true},
+			"https://example.com/",
+			&network.Response{Status: 200, MimeType: "application/xhtml+xml"},
+			"",
+		},
+		{
+			"not html",
+			RendererConf{RequireHTML: true},
+			"https://example.com/data.json",
+			&network.Response{Status: 200, MimeType: "application/json"},
+			ResponseErrorContentType,
+		},
+		{"without response", RendererConf{RequireHTML: true}, "data:text/plain,hi", nil, ""},
+		{
+			"host allowed",
+			RendererConf{AllowedHosts: []string{"example.com", "*.example.com"}},
+			"https://www.example.com:8443/",
+			html,
+			"",
+		},
+		{
+			"host not allowed",
+			RendererConf{AllowedHosts: []string{"example.com"}},
+			"https://login.example.org/",
+			html,
+			ResponseErrorHost,
+		},
+		{
+			"host checked without response",
+			RendererConf{AllowedHosts: []string{"example.com"}},
+			"about:blank",
+			nil,
+			ResponseErrorHost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := responseError(tt.conf, tt.finalURL, tt.resp)
+			if tt.reason == "" {
+				if err != nil {
+					t.Errorf("responseError() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Reason != tt.reason || err.URL != tt.finalURL {
+				t.Errorf("responseError() = %+v, want reason %s", err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestAllowedHost(t *testing.T) {
+	hosts := []string{"Example.com", "*.cdn.example.net"}
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://example.com/page", true},
+		{"https://EXAMPLE.com:443/", true},
+		{"https://www.example.com/", false},
+		{"https://img.cdn.example.net/", true},
+		{"https://cdn.example.net/", false},
+		{"https://evilexample.com/", false},
+		{"::invalid", false},
+	}
+	for _, tt := range tests {
+		if got := allowedHost(hosts, tt.url); got != tt.want {
+			t.Errorf("allowedHost(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestInterceptorHost(t *testing.T) {
+	i := newInterceptor(RendererConf{AllowedHosts: []string{"example.com"}}, "https://example.com/")
+	if !i.enabled() {
+		t.Fatal("interceptor not enabled with allowed hosts")
+	}
+	i.setMainFrame("main")
+
+	tests := []struct {
+		frameID      cdp.FrameID
+		resourceType network.ResourceType
+		url          string
+		denied       bool
+	}{
+		{"main", network.ResourceTypeDocument, "https://example.com/", false},
+		{"main", network.ResourceTypeScript, "https://cdn.example.net/app.js", false},
+		{"iframe", network.ResourceTypeDocument, "https://ads.example.net/", false},
+		{"main", network.ResourceTypeDocument, "https://login.example.org/", true},
+	}
+	for _, tt := range tests {
+		e := &fetch.EventRequestPaused{
+			RequestID:    "paused",
+			FrameID:      tt.frameID,
+			ResourceType: tt.resourceType,
+			Request:      &network.Request{URL: tt.url},
+		}
+		action := i.handlers[0](e, fetch.ContinueRequest(e.RequestID))
+		fail, ok := action.(*fetch.FailRequestParams)
+		if tt.denied && (!ok || fail.ErrorReason != network.ErrorReasonBlockedByClient) {
+			t.Errorf("handler(%s, %q) = %#v, want fail blocked by client", tt.frameID, tt.url, action)
+		}
+		if !tt.denied && action != nil {
+			t.Errorf("handler(%s, %q) = %#v, want nil", tt.frameID, tt.url, action)
+		}
+		if !tt.denied && i.hostError() != nil {
+			t.Errorf("hostError() after %q = %v, want nil", tt.url, i.hostError())
+		}
+	}
+
+	select {
+	case <-i.hostDenied():
+	default:
+		t.Error("hostDenied() not closed after denied request")
+	}
+	// the first denied url is reported
+	i.handlers[0](&fetch.EventRequestPaused{
+		FrameID:      "main",
+		ResourceType: network.ResourceTypeDocument,
+		Request:      &network.Request{URL: "https://other.example.org/"},
+	}, nil)
+	respErr := i.hostError()
+	if respErr == nil || respErr.Reason != ResponseErrorHost || respErr.URL != "https://login.example.org/" {
+		t.Errorf("hostError() = %+v, want host error of login.example.org", respErr)
+	}
+}
+
+func TestValidateRendererConf(t *testing.T) {
+	tests := []struct {
+		conf  RendererConf
+		valid bool
+	}{
+		{RendererConf{}, true},
+		{RendererConf{FailStatus: []StatusRange{{Min: 404, Max: 404}}}, true},
+		{RendererConf{FailStatus: []StatusRange{{Min: 599, Max: 500}}}, false},
+		{RendererConf{AllowedHosts: []string{"*."}}, false},
+	}
+	for _, tt := range tests {
+		err := validateRendererConf(tt.conf)
+		if tt.valid && err != nil {
+			t.Errorf("validateRendererConf(%+v) = %v, want nil", tt.conf, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidOption) {
+			t.Errorf("validateRendererConf(%+v) = %v, want ErrInvalidOption", tt.conf, err)
+		}
+	}
+}
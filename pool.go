@@ -0,0 +1,229 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/chromedp/cdproto/inspector"
+	"github.com/chromedp/chromedp"
+)
+
+// PoolConf is the configuration of BrowserPool
+type PoolConf struct {
+	// Number of browser processes in the pool
+	Size int
+	// Maximum concurrent tabs of each browser process
+	MaxTabs int
+	// Browser process is restarted after this many renders, 0 for no limit
+	MaxRenders int
+	// Maximum renders waiting for a free tab, 0 for no limit
+	MaxQueue int
+}
+
+var DefaultPoolConf = PoolConf{
+	Size:    1,
+	MaxTabs: 4,
+}
+
+// BrowserPool is a pool of long-lived browsers with bounded concurrent tabs. Renders
+// wait in queue when all tabs are busy. Browser is restarted after serving
+// MaxRenders renders or when one of its tabs crashed.
+type BrowserPool struct {
+	browserConf  BrowserConf
+	rendererConf RendererConf
+	conf         PoolConf
+
+	mu      sync.Mutex
+	slots   []*poolSlot
+	waiting int
+	wake    chan struct{} // closed and replaced when a tab is released
+	closed  bool
+}
+
+// poolSlot is a browser of the pool with its usage records
+type poolSlot struct {
+	browser *Browser
+	active  int // tabs in use
+	renders int // renders served since browser started
+	crashed bool
+}
+
+// NewBrowserPool create new browser pool with the given configuration. Browsers of
+// the pool are started on demand. Use WithBrowserPool to render with the pool.
+func NewBrowserPool(browserConf BrowserConf, rendererConf RendererConf, conf PoolConf) *BrowserPool {
+	if conf.Size <= 0 {
+		conf.Size = DefaultPoolConf.Size
+	}
+	if conf.MaxTabs <= 0 {
+		conf.MaxTabs = DefaultPoolConf.MaxTabs
+	}
+
+	p := &BrowserPool{
+		browserConf:  browserConf,
+		rendererConf: rendererConf,
+		conf:         conf,
+		wake:         make(chan struct{}),
+	}
+	for i := 0; i < conf.Size; i++ {
+		p.slots = append(p.slots, &poolSlot{browser: NewBrowser(browserConf, rendererConf)})
+	}
+
+	return p
+}
+
+// Close closes every browser in the pool, renders in progress are cancelled.
+func (p *BrowserPool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.wake)
+	slots := p.slots
+	p.mu.Unlock()
+
+	var errs []error
+	for _, slot := range slots {
+		errs = append(errs, slot.browser.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// newTab waits for a free slot of the pool and opens a new tab in its browser. The
+// slot is released when the returned cancel function is called.
+func (p *BrowserPool) newTab(
+	ctx context.Context,
+	browserConf BrowserConf,
+	conf RendererConf,
+	logger *slog.Logger,
+) (context.Context, context.CancelFunc, error) {
+	slot, b, err := p.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tabCtx, cancel, err := b.newTab(ctx, browserConf, conf, logger)
+	if err != nil {
+		p.release(slot)
+		return nil, nil, err
+	}
+
+	chromedp.ListenTarget(tabCtx, func(ev any) {
+		if _, ok := ev.(*inspector.EventTargetCrashed); ok {
+			logger.Warn("browser tab crashed, restart browser when idle")
+			p.markCrashed(slot)
+			go cancel()
+		}
+	})
+
+	var once sync.Once
+	return tabCtx, func() {
+		once.Do(func() {
+			cancel()
+			p.release(slot)
+		})
+	}, nil
+}
+
+func (p *BrowserPool) defaultProxy() string {
+	return p.browserConf.Proxy
+}
+
+func (p *BrowserPool) remote() bool {
+	return p.browserConf.RemoteURL != ""
+}
+
+// acquire blocks until a tab is available in the pool or ctx is done
+func (p *BrowserPool) acquire(ctx context.Context) (*poolSlot, *Browser, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		if p.closed {
+			return nil, nil, ErrBrowserClosed
+		}
+		if slot := p.available(); slot != nil {
+			slot.active++
+			slot.renders++
+			return slot, slot.browser, nil
+		}
+		if p.conf.MaxQueue > 0 && p.waiting >= p.conf.MaxQueue {
+			return nil, nil, ErrPoolBusy
+		}
+
+		wake := p.wake
+		p.waiting++
+		p.mu.Unlock()
+		select {
+		case <-wake:
+			p.mu.Lock()
+			p.waiting--
+		case <-ctx.Done():
+			p.mu.Lock()
+			p.waiting--
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+// available returns the least busy slot with a free tab, browser waiting to be
+// restarted is recycled here once all of its tabs are closed. Must be called with
+// p.mu held.
+func (p *BrowserPool) available() *poolSlot {
+	var found *poolSlot
+	for _, slot := range p.slots {
+		if p.retiring(slot) {
+			if slot.active > 0 {
+				continue
+			}
+			p.recycle(slot)
+		}
+		if slot.active >= p.conf.MaxTabs {
+			continue
+		}
+		if found == nil || slot.active < found.active {
+			found = slot
+		}
+	}
+	return found
+}
+
+// retiring reports whether the browser of slot should be restarted. Must be called
+// with p.mu held.
+func (p *BrowserPool) retiring(slot *poolSlot) bool {
+	return slot.crashed || (p.conf.MaxRenders > 0 && slot.renders >= p.conf.MaxRenders)
+}
+
+// recycle replaces the browser of slot with a new one and closes the old browser in
+// background. Must be called with p.mu held.
+func (p *BrowserPool) recycle(slot *poolSlot) {
+	old := slot.browser
+	slot.browser = NewBrowser(p.browserConf, p.rendererConf)
+	slot.renders = 0
+	slot.crashed = false
+	go old.Close()
+}
+
+func (p *BrowserPool) release(slot *poolSlot) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	slot.active--
+	if p.closed {
+		return
+	}
+	if p.retiring(slot) && slot.active == 0 {
+		p.recycle(slot)
+	}
+	close(p.wake)
+	p.wake = make(chan struct{})
+}
+
+func (p *BrowserPool) markCrashed(slot *poolSlot) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	slot.crashed = true
+}
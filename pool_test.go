@@ -0,0 +1,135 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestPool(conf PoolConf) *BrowserPool {
+	return NewBrowserPool(DefaultRendererOption.BrowserOpts, DefaultRendererConf, conf)
+}
+
+func TestBrowserPoolBoundedTabs(t *testing.T) {
+	p := newTestPool(PoolConf{Size: 2, MaxTabs: 2})
+	defer p.Close()
+
+	var slots []*poolSlot
+	for i := 0; i < 4; i++ {
+		slot, _, err := p.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+		slots = append(slots, slot)
+	}
+	for _, slot := range p.slots {
+		if slot.active != 2 {
+			t.Errorf("slot active tabs = %d, want 2", slot.active)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := p.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire on full pool error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	acquired := make(chan error, 1)
+	go func() {
+		_, _, err := p.acquire(context.Background())
+		acquired <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	p.release(slots[0])
+
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("queued acquire: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("queued acquire not woken by release")
+	}
+}
+
+func TestBrowserPoolMaxQueue(t *testing.T) {
+	p := newTestPool(PoolConf{Size: 1, MaxTabs: 1, MaxQueue: 1})
+	defer p.Close()
+
+	if _, _, err := p.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.acquire(ctx)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, _, err := p.acquire(context.Background()); !errors.Is(err, ErrPoolBusy) {
+		t.Fatalf("acquire with full queue error = %v, want %v", err, ErrPoolBusy)
+	}
+}
+
+func TestBrowserPoolRecycle(t *testing.T) {
+	p := newTestPool(PoolConf{Size: 1, MaxTabs: 2, MaxRenders: 2})
+	defer p.Close()
+
+	first, b, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	second, _, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	// browser reached MaxRenders, no more tab until it is restarted
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := p.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire on retiring browser error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	p.release(first)
+	if p.slots[0].browser != b {
+		t.Fatal("browser restarted while tab in use")
+	}
+	p.release(second)
+	if p.slots[0].browser == b {
+		t.Fatal("browser not restarted after MaxRenders")
+	}
+	if p.slots[0].renders != 0 {
+		t.Errorf("renders after restart = %d, want 0", p.slots[0].renders)
+	}
+}
+
+func TestBrowserPoolCrashed(t *testing.T) {
+	p := newTestPool(PoolConf{Size: 1, MaxTabs: 1})
+	defer p.Close()
+
+	slot, b, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	p.markCrashed(slot)
+	p.release(slot)
+
+	if p.slots[0].browser == b {
+		t.Fatal("crashed browser not restarted")
+	}
+	if p.slots[0].crashed {
+		t.Error("restarted browser still marked as crashed")
+	}
+}
+
+func TestBrowserPoolClosed(t *testing.T) {
+	p := newTestPool(DefaultPoolConf)
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, _, err := p.acquire(context.Background()); !errors.Is(err, ErrBrowserClosed) {
+		t.Fatalf("acquire on closed pool error = %v, want %v", err, ErrBrowserClosed)
+	}
+}
@@ -0,0 +1,66 @@
+package renderer
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/chromedp/cdproto/target"
+	"github.com/chromedp/chromedp"
+)
+
+// validateProxy checks if proxy url is valid, chrome does not support authentication
+// of socks proxy.
+func validateProxy(proxy string) error {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("%w: invalid proxy %s", ErrInvalidOption, proxy)
+	}
+	if !slices.Contains([]string{"http", "https", "socks4", "socks5"}, u.Scheme) {
+		return fmt.Errorf("%w: unsupported proxy scheme %s", ErrInvalidOption, u.Scheme)
+	}
+	if u.User != nil && strings.HasPrefix(u.Scheme, "socks") {
+		return fmt.Errorf("%w: socks proxy authentication is not supported", ErrInvalidOption)
+	}
+	return nil
+}
+
+// proxyServer returns proxy server for chrome, which is the proxy url without
+// credentials
+func proxyServer(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return proxy
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// proxyAuth returns credentials of the proxy url, nil if there is none
+func proxyAuth(proxy string) *BasicAuth {
+	u, err := url.Parse(proxy)
+	if err != nil || u.User == nil {
+		return nil
+	}
+	password, _ := u.User.Password()
+	return &BasicAuth{Username: u.User.Username(), Password: password}
+}
+
+// proxyBypassList returns proxy bypass list for chrome
+func proxyBypassList(bypass []string) string {
+	return strings.Join(bypass, ";")
+}
+
+// withProxy sets proxy of the browser context to create
+func withProxy(conf BrowserConf) chromedp.CreateBrowserContextOption {
+	return func(p *target.CreateBrowserContextParams) *target.CreateBrowserContextParams {
+		if conf.Proxy == "" {
+			return p
+		}
+		p = p.WithProxyServer(proxyServer(conf.Proxy))
+		if len(conf.ProxyBypass) > 0 {
+			p = p.WithProxyBypassList(proxyBypassList(conf.ProxyBypass))
+		}
+		return p
+	}
+}
@@ -6,101 +6,173 @@ import (
 	"fmt"
 	"log/slog"
 	"math"
-	"slices"
 	"time"
 
 	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/fetch"
 	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
 )
 
+// Renderer is safe for concurrent use by multiple goroutines, every render owns
+// its own wait state.
 type Renderer struct {
-	logger           *slog.Logger
+	logger          *slog.Logger
+	idleWait        time.Duration // network idle check window
+	idleMaxInflight int           // network idle check maximum inflight requests
+	idleFilter      idleFilter    // requests counted by network idle check
+	browser         tabOpener     // long-lived browser, launch browser per render if nil
+	retry           retryPolicy   // retry of renders failed with transient errors
+}
+
+// tabOpener opens browser tab for a single render from long-lived browser,
+// implemented by Browser and BrowserPool.
+type tabOpener interface {
+	newTab(
+		ctx context.Context,
+		browserConf BrowserConf,
+		rendererConf RendererConf,
+		logger *slog.Logger,
+	) (context.Context, context.CancelFunc, error)
+	// defaultProxy returns proxy of renders which do not set their own proxy
+	defaultProxy() string
+	// remote reports whether tabs are opened in a remote browser, which is not
+	// launched with renderer config
+	remote() bool
+	Close() error
+}
+
+// renderState keeps the wait state of a single render, so concurrent renders
+// sharing the same Renderer do not interfere with each other.
+type renderState struct {
 	idleCheck        *networkIdle     // use for network idle check
 	interactiveCheck *interactiveTime // use for interactive time check
+	lifecycle        *lifecycle       // lifecycle events of main frame
+	documents        *documentRecord  // main document responses
+	console          *consoleRecord   // console messages and uncaught exceptions
+	interceptor      *interceptor     // handle requests paused by Fetch interception
+	har              *harRecorder     // record network activity, nil if not recording
+	frameID          cdp.FrameID      // main frame of the navigation
+	timings          RenderTimings
+	waitEndedBy      string
 }
 
 // NewRenderer create new renderer instance. Function options can be pass as argument
 // to set for configuration (logger for now)
 func NewRenderer(options ...WithOption) *Renderer {
 	r := &Renderer{
-		logger:           slog.Default(),
-		interactiveCheck: newInteractiveTime(),
+		logger:          slog.Default(),
+		idleWait:        defaultNetworkIdleWait,
+		idleMaxInflight: defaultNetworkIdleMaxInflight,
+		idleFilter:      newIdleFilter(),
 	}
 
 	for _, option := range options {
 		option(r)
 	}
 
-	if r.idleCheck == nil {
-		r.idleCheck = newNetworkIdle(defaultNetworkIdleWait, defaultNetworkIdleMaxInflight)
+	return r
+}
+
+// Close closes the long-lived browser of the renderer if it is set by WithBrowser
+// or WithBrowserPool
+func (r *Renderer) Close() error {
+	if r.browser == nil {
+		return nil
 	}
+	return r.browser.Close()
+}
 
-	return r
+// newRenderState create fresh wait state for a single render from the renderer settings
+func (r *Renderer) newRenderState() *renderState {
+	return &renderState{
+		idleCheck:        newNetworkIdle(r.idleWait, r.idleMaxInflight, r.idleFilter),
+		interactiveCheck: newInteractiveTime(),
+		lifecycle:        newLifecycle(),
+		documents:        newDocumentRecord(),
+		console:          newConsoleRecord(),
+		interceptor:      newInterceptor(RendererConf{}, ""),
+	}
 }
 
 // RenderPage render the given url with automated chrome browser and return back the
 // result html content. RendererOption is use for setting the behavior of the automated
 // browser while rendering the page.
 func (r *Renderer) RenderPage(urlStr string, opts *RendererOption) ([]byte, error) {
-	if opts == nil {
-		opts = &DefaultRendererOption
-	}
+	return r.RenderPageContext(context.Background(), urlStr, opts)
+}
 
-	if !IsValidIdleType(opts.BrowserOpts.IdleType) {
-		return nil, fmt.Errorf("invalid idleType %s", opts.BrowserOpts.IdleType)
+// RenderPageContext is like RenderPage but stops rendering as soon as ctx is done.
+// Cancelling ctx closes the automated browser, and a deadline of ctx takes effect
+// together with the Timeout option, whichever comes first.
+func (r *Renderer) RenderPageContext(
+	ctx context.Context,
+	urlStr string,
+	opts *RendererOption,
+) ([]byte, error) {
+	res, err := r.RenderPageResult(ctx, urlStr, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	chromeOpts := setChromeOpts(opts)
+	return res.Content, nil
+}
 
-	start := time.Now()
-	ctx, cancel := chromedp.NewExecAllocator(context.Background(), chromeOpts...)
-	defer cancel()
-	if opts.BrowserOpts.ChromiumDebug {
-		ctx, cancel = chromedp.NewContext(ctx, chromedp.WithDebugf(r.logger.Debug))
-	} else {
-		ctx, cancel = chromedp.NewContext(ctx)
+// RenderPageResult is like RenderPageContext but returns RenderResult with details of
+// the rendered page along with the html content. RenderResult is also returned with
+// error if the render failed after the browser tab is opened.
+func (r *Renderer) RenderPageResult(
+	ctx context.Context,
+	urlStr string,
+	opts *RendererOption,
+) (*RenderResult, error) {
+	if opts == nil {
+		opts = &DefaultRendererOption
 	}
-	defer cancel()
 
-	// Attach listener before navigating to the page
-	r.Listen(ctx, opts.BrowserOpts)
-
-	var resp string
-	err := chromedp.Run(ctx,
-		chromedp.Tasks{
-			network.Enable(),
-			r.navigateAndWaitFor(urlStr, *opts),
-			chromedp.ActionFunc(func(ctx context.Context) error {
-				node, err := dom.GetDocument().Do(ctx)
-				if err != nil {
-					r.logger.Error(err.Error(), slog.String("url", urlStr))
-					return fmt.Errorf("renderPage(%v): %w", urlStr, err)
-				}
-				resp, err = dom.GetOuterHTML().WithNodeID(node.NodeID).Do(ctx)
-				if err != nil {
-					r.logger.Error(err.Error(), slog.String("url", urlStr))
-					return fmt.Errorf("renderPage(%v): %w", urlStr, err)
-				}
-				return nil
-			}),
-		},
-	)
-	duration := time.Since(start)
-	r.logger.Debug(fmt.Sprintf("Render time: %v", duration), slog.String("url", urlStr))
+	return r.render(ctx, urlStr, *opts, func(ctx context.Context) ([]byte, error) {
+		resp, err := captureHTML(ctx)
+		if err != nil {
+			r.logger.Error(err.Error(), slog.String("url", urlStr))
+			return nil, fmt.Errorf("renderPage(%v): %w", urlStr, err)
+		}
+		return resp, nil
+	})
+}
+
+// RenderPdf generate and return the pdf as byte array from the given url using
+// automated chrome browser. PdfOption is for setting the style of the generated pdf.
+func (r *Renderer) RenderPdf(urlStr string, opts *PdfOption) ([]byte, error) {
+	return r.RenderPdfContext(context.Background(), urlStr, opts)
+}
+
+// RenderPdfContext is like RenderPdf but stops rendering as soon as ctx is done.
+// Cancelling ctx closes the automated browser, and a deadline of ctx takes effect
+// together with the Timeout option, whichever comes first.
+func (r *Renderer) RenderPdfContext(
+	ctx context.Context,
+	urlStr string,
+	opts *PdfOption,
+) ([]byte, error) {
+	res, err := r.RenderPdfResult(ctx, urlStr, opts)
 	if err != nil {
-		r.logger.Error(fmt.Sprintf("chromedp run error: %s", err), slog.String("url", urlStr))
 		return nil, err
 	}
 
-	return []byte(resp), nil
+	return res.Content, nil
 }
 
-// RenderPdf generate and return the pdf as byte array from the given url using
-// automated chrome browser. PdfOption is for setting the style of the generated pdf.
-func (r *Renderer) RenderPdf(urlStr string, opts *PdfOption) ([]byte, error) {
+// RenderPdfResult is like RenderPdfContext but returns RenderResult with details of
+// the rendered page along with the pdf. RenderResult is also returned with error if
+// the render failed after the browser tab is opened.
+func (r *Renderer) RenderPdfResult(
+	ctx context.Context,
+	urlStr string,
+	opts *PdfOption,
+) (*RenderResult, error) {
 	pdfParams := page.PrintToPDF()
 	if opts == nil {
 		opts = &DefaultPdfOption
@@ -108,63 +180,333 @@ func (r *Renderer) RenderPdf(urlStr string, opts *PdfOption) ([]byte, error) {
 		pdfParams = opts.setParams()
 	}
 
-	if !IsValidIdleType(opts.BrowserOpts.IdleType) {
-		return nil, fmt.Errorf("invalid idleType %s", opts.BrowserOpts.IdleType)
+	return r.render(ctx, urlStr, *opts, func(ctx context.Context) ([]byte, error) {
+		buf, _, err := pdfParams.Do(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("renderPdf(%v): %w", urlStr, err)
+		}
+		return buf, nil
+	})
+}
+
+// RenderScreenshot capture and return the image of the given url using automated
+// chrome browser. ScreenshotOption is for setting the area and format of the image.
+func (r *Renderer) RenderScreenshot(urlStr string, opts *ScreenshotOption) ([]byte, error) {
+	return r.RenderScreenshotContext(context.Background(), urlStr, opts)
+}
+
+// RenderScreenshotContext is like RenderScreenshot but stops rendering as soon as ctx
+// is done. Cancelling ctx closes the automated browser, and a deadline of ctx takes
+// effect together with the Timeout option, whichever comes first.
+func (r *Renderer) RenderScreenshotContext(
+	ctx context.Context,
+	urlStr string,
+	opts *ScreenshotOption,
+) ([]byte, error) {
+	res, err := r.RenderScreenshotResult(ctx, urlStr, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Content, nil
+}
+
+// RenderScreenshotResult is like RenderScreenshotContext but returns RenderResult with
+// details of the rendered page along with the image. RenderResult is also returned
+// with error if the render failed after the browser tab is opened.
+func (r *Renderer) RenderScreenshotResult(
+	ctx context.Context,
+	urlStr string,
+	opts *ScreenshotOption,
+) (*RenderResult, error) {
+	if opts == nil {
+		opts = &DefaultScreenshotOption
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	return r.render(ctx, urlStr, *opts, func(ctx context.Context) ([]byte, error) {
+		buf, err := opts.capture(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("renderScreenshot(%v): %w", urlStr, err)
+		}
+		return buf, nil
+	})
+}
+
+// RenderArtifacts navigates to the given url and waits for the page once, then
+// produces every output requested by ArtifactOption from the same page state. The
+// outputs are set to Artifacts of RenderResult, while Content is left empty.
+func (r *Renderer) RenderArtifacts(
+	ctx context.Context,
+	urlStr string,
+	opts *ArtifactOption,
+) (*RenderResult, error) {
+	if opts == nil {
+		return nil, fmt.Errorf("%w: nil artifact option", ErrInvalidOption)
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	artifacts := &Artifacts{}
+	res, err := r.render(ctx, urlStr, *opts, func(ctx context.Context) ([]byte, error) {
+		if err := opts.capture(ctx, artifacts); err != nil {
+			return nil, fmt.Errorf("renderArtifacts(%v): %w", urlStr, err)
+		}
+		return nil, nil
+	})
+	if res != nil {
+		res.Artifacts = artifacts
 	}
 
-	chromeOpts := setChromeOpts(opts)
+	return res, err
+}
+
+// captureHTML returns outer html of the document
+func captureHTML(ctx context.Context) ([]byte, error) {
+	node, err := dom.GetDocument().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	html, err := dom.GetOuterHTML().WithNodeID(node.NodeID).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(html), nil
+}
+
+// captureFunc produces render output from the loaded page
+type captureFunc func(ctx context.Context) ([]byte, error)
+
+// render renders the url, retrying with backoff when the render failed with
+// transient error if retry is set by WithRetry. Every attempt is recorded in the
+// result of the last attempt.
+func (r *Renderer) render(
+	ctx context.Context,
+	urlStr string,
+	opts chromedpOption,
+	capture captureFunc,
+) (*RenderResult, error) {
+	return r.retryRender(ctx, urlStr, func() (*RenderResult, error) {
+		return r.renderOnce(ctx, urlStr, opts, capture)
+	})
+}
+
+// renderOnce launches automated browser to navigate to the given url, waits until
+// the page is ready and runs capture against the loaded page.
+func (r *Renderer) renderOnce(
+	ctx context.Context,
+	urlStr string,
+	opts chromedpOption,
+	capture captureFunc,
+) (*RenderResult, error) {
+	browserConf := opts.readBrowserConf()
+	if err := validateBrowserConf(browserConf); err != nil {
+		return nil, err
+	}
+	if err := validateRendererConf(opts.readRendererConf()); err != nil {
+		return nil, err
+	}
 
 	start := time.Now()
-	ctx, cancel := chromedp.NewExecAllocator(context.Background(), chromeOpts...)
-	defer cancel()
-	if opts.BrowserOpts.ChromiumDebug {
-		ctx, cancel = chromedp.NewContext(ctx, chromedp.WithDebugf(r.logger.Debug))
-	} else {
-		ctx, cancel = chromedp.NewContext(ctx)
+	tabCtx, cancel, err := r.newTab(ctx, opts)
+	if err != nil {
+		r.logger.Error(fmt.Sprintf("new tab error: %s", err), slog.String("url", urlStr))
+		return nil, err
 	}
 	defer cancel()
 
 	// Attach listener before navigating to the page
-	r.Listen(ctx, opts.BrowserOpts)
+	state := r.newRenderState()
+	remote := browserConf.RemoteURL != ""
+	if r.browser != nil {
+		remote = r.browser.remote()
+	}
+	state.interceptor = newInterceptor(tabRendererConf(opts.readRendererConf(), remote), urlStr)
+	state.interceptor.proxyAuth = proxyAuth(r.proxyFor(opts.readBrowserConf()))
+	if conf := opts.readRendererConf(); conf.RecordHAR {
+		state.har = newHARRecorder(conf.HARBodyLimit, r.logger)
+	}
+	if c := chromedp.FromContext(tabCtx); c != nil && c.Target != nil {
+		// main frame shares the ID of the page target
+		state.interceptor.setMainFrame(cdp.FrameID(c.Target.TargetID))
+	}
+	state.timings.Tab = time.Since(start)
+	r.listen(tabCtx, state)
 
-	var resp []byte
-	err := chromedp.Run(ctx,
+	res := &RenderResult{URL: urlStr}
+	err = chromedp.Run(tabCtx,
 		network.Enable(),
-		r.navigateAndWaitFor(urlStr, *opts),
+		state.interceptor.enable(),
+		setupRequests(opts.readRendererConf(), urlStr),
+		r.navigateAndWaitFor(urlStr, opts, state),
+		chromedp.Location(&res.FinalURL),
+		chromedp.Title(&res.Title),
+		checkResponse(opts.readRendererConf(), state, &res.FinalURL),
 		chromedp.ActionFunc(func(ctx context.Context) error {
-			buf, _, err := pdfParams.Do(ctx)
+			captureStart := time.Now()
+			defer func() {
+				state.timings.Capture = time.Since(captureStart)
+			}()
+
+			content, err := capture(ctx)
 			if err != nil {
-				return fmt.Errorf("renderPdf(%v): %w", urlStr, err)
+				return err
 			}
-			resp = buf
+			res.Content = content
 			return nil
 		}),
 	)
-	duration := time.Since(start)
-	r.logger.Debug(fmt.Sprintf("Render time: %v", duration), slog.String("url", urlStr))
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil && !errors.Is(err, ctxErr) {
+		// tab of long-lived browser is closed by ctx, report the reason of ctx
+		err = fmt.Errorf("%w: %w", ctxErr, err)
+	} else if err != nil && ctxErr == nil && tabCtx.Err() != nil {
+		// tab is gone while ctx is not done, the browser exited or disconnected
+		err = fmt.Errorf("%w: %w", ErrBrowserCrashed, err)
+	}
+	state.timings.Total = time.Since(start)
+	state.documents.fill(state.frameID, res)
+	res.Timings = state.timings
+	res.WaitEndedBy = state.waitEndedBy
+	res.Idle = state.idleCheck.report()
+	res.Blocked = state.interceptor.blockedRequests()
+	res.ReplayMisses = state.interceptor.replayMisses()
+	res.HAR = state.har.build(res.Title, state.lifecycle)
+	state.console.fill(res)
+
+	r.logger.Debug(fmt.Sprintf("Render time: %v", res.Timings.Total), slog.String("url", urlStr))
 	if err != nil {
 		r.logger.Error(fmt.Sprintf("chromedp run error: %s", err), slog.String("url", urlStr))
-		return nil, err
+		return res, err
+	}
+	if opts.readRendererConf().FailOnJSError {
+		if err := state.console.jsError(); err != nil {
+			r.logger.Error(fmt.Sprintf("page error: %s", err), slog.String("url", urlStr))
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+// newTab returns chromedp context of a browser tab for a single render. The tab is
+// opened in the long-lived browser if set, or in an isolated browser context of the
+// remote browser if RemoteURL is set. Otherwise a new browser is launched and closed
+// together with the tab.
+func (r *Renderer) newTab(
+	ctx context.Context,
+	opts chromedpOption,
+) (context.Context, context.CancelFunc, error) {
+	if r.browser != nil {
+		return r.browser.newTab(ctx, opts.readBrowserConf(), opts.readRendererConf(), r.logger)
 	}
 
-	return resp, nil
+	browserConf := opts.readBrowserConf()
+	allocCtx, allocCancel := newAllocator(ctx, opts)
+	var contextOpts []chromedp.ContextOption
+	if browserConf.ChromiumDebug {
+		contextOpts = append(contextOpts, chromedp.WithDebugf(r.logger.Debug))
+	}
+	if browserConf.RemoteURL != "" {
+		// launch flags are not applied to remote browser, set proxy of the context
+		contextOpts = append(contextOpts, chromedp.WithNewBrowserContext(withProxy(browserConf)))
+	}
+	tabCtx, cancel := chromedp.NewContext(allocCtx, contextOpts...)
+	cancelTab := func() {
+		cancel()
+		allocCancel()
+	}
+
+	// first run allocates the browser and opens the tab
+	if err := chromedp.Run(tabCtx); err != nil {
+		cancelTab()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+		return nil, nil, fmt.Errorf("%w: %w", ErrBrowserLaunch, err)
+	}
+	if browserConf.RemoteURL != "" {
+		if err := chromedp.Run(tabCtx, setupTab(opts.readRendererConf(), nil)); err != nil {
+			cancelTab()
+			return nil, nil, err
+		}
+	}
+
+	return tabCtx, cancelTab, nil
+}
+
+// proxyFor returns proxy used by render of the browser config, which is the proxy of
+// long-lived browser if the render does not set its own.
+func (r *Renderer) proxyFor(conf BrowserConf) string {
+	if conf.Proxy == "" && r.browser != nil {
+		return r.browser.defaultProxy()
+	}
+	return conf.Proxy
 }
 
 // navigateAndWaitFor is defined as task of chromedp for rendering step
-func (r *Renderer) navigateAndWaitFor(url string, opts chromedpOption) chromedp.ActionFunc {
+func (r *Renderer) navigateAndWaitFor(
+	url string,
+	opts chromedpOption,
+	state *renderState,
+) chromedp.ActionFunc {
 	return func(ctx context.Context) error {
-		_, _, _, _, err := page.Navigate(url).Do(ctx)
+		start := time.Now()
+		frameID, _, errorText, _, err := page.Navigate(url).Do(ctx)
+		state.timings.Navigation = time.Since(start)
 		if err != nil {
 			return err
 		}
+		if respErr := state.interceptor.hostError(); respErr != nil {
+			// redirected to host not allowed, navigation is failed by the interceptor
+			return respErr
+		}
+		if errorText != "" {
+			return &NavigationError{URL: url, Code: errorText}
+		}
+		state.frameID = frameID
 
-		return r.waitFor(ctx, opts)
+		start = time.Now()
+		defer func() {
+			state.timings.Wait = time.Since(start)
+		}()
+
+		// stop waiting once the page navigates to host not allowed
+		waitCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			select {
+			case <-state.interceptor.hostDenied():
+				cancel()
+			case <-waitCtx.Done():
+			}
+		}()
+		err = r.waitFor(waitCtx, opts, state)
+		if respErr := state.interceptor.hostError(); respErr != nil {
+			return respErr
+		}
+		return err
 	}
 }
 
+// Listen attaches event listener which tracks network and lifecycle events of the
+// browser tab of ctx in a new wait state.
+//
+// Deprecated: wait state is owned by every render since renders may run
+// concurrently, so the state attached by Listen is not used by any render. conf is
+// ignored. Listen will be removed in a future release.
 func (r *Renderer) Listen(ctx context.Context, conf BrowserConf) {
+	r.listen(ctx, r.newRenderState())
+}
+
+// listen attaches event listener for the render to update its wait state
+func (r *Renderer) listen(ctx context.Context, state *renderState) {
 	var mainFrame cdp.FrameID
 	chromedp.ListenTarget(ctx, func(ev any) {
+		state.har.record(ctx, ev)
+
 		switch e := ev.(type) {
 		case *page.EventFrameNavigated:
 			if e.Frame.ParentID == "" {
@@ -172,17 +514,25 @@ func (r *Renderer) Listen(ctx context.Context, conf BrowserConf) {
 			}
 
 		case *network.EventRequestWillBeSent:
-			if !enabledIdleType([]string{"auto", "networkIdle"}, conf.IdleType) {
+			if e.Type == network.ResourceTypeDocument && e.RedirectResponse != nil {
+				state.documents.addRedirect(e.FrameID, e.RedirectResponse)
+			}
+
+			if state.interceptor.requestBlocked(e) {
+				// failed by interceptor, never counted by network idle check. Request
+				// redirected to blocked url is tracked by its previous hop.
+				state.idleCheck.untrack(e.RequestID)
 				return
 			}
-			if isNoisyRequest(e) {
+			if state.idleCheck.filter.ignored(e, mainFrame) {
+				state.idleCheck.ignoreRequest(e.RequestID, e.Type, e.Request.URL)
 				return
 			}
 
 			var activeLen int
 			var msg string
 			if e.Type == network.ResourceTypeDocument && e.FrameID == mainFrame {
-				activeLen = r.idleCheck.addByLoader(e.LoaderID, e.RequestID, e.Type, e.Request.URL)
+				activeLen = state.idleCheck.addByLoader(e.LoaderID, e.RequestID, e.Type, e.Request.URL)
 				msg = fmt.Sprintf(
 					"Type: network.EventRequestWillBeSent - %s, mainFrameID: %s, FrameID: %s, LoaderID: %s, ID: %s, Active: %d",
 					e.Type,
@@ -193,7 +543,7 @@ func (r *Renderer) Listen(ctx context.Context, conf BrowserConf) {
 					activeLen,
 				)
 			} else if e.Type != network.ResourceTypeDocument {
-				activeLen = r.idleCheck.add(e.RequestID, e.Type, e.Request.URL)
+				activeLen = state.idleCheck.add(e.RequestID, e.Type, e.Request.URL)
 				msg = fmt.Sprintf(
 					"Type: network.EventRequestWillBeSent - %s, mainFrameID: %s, FrameID: %s, ID: %s, Active: %d",
 					e.Type,
@@ -204,11 +554,12 @@ func (r *Renderer) Listen(ctx context.Context, conf BrowserConf) {
 				)
 			}
 			r.logger.Debug(msg)
-		case *network.EventLoadingFinished:
-			if !enabledIdleType([]string{"auto", "networkIdle"}, conf.IdleType) {
-				return
+		case *network.EventResponseReceived:
+			if e.Type == network.ResourceTypeDocument {
+				state.documents.addResponse(e.FrameID, e.RequestID, e.Response)
 			}
-			activeLen := r.idleCheck.remove(e.RequestID)
+		case *network.EventLoadingFinished:
+			activeLen := state.idleCheck.remove(e.RequestID)
 			msg := fmt.Sprintf(
 				"Type: network.EventLoadingFinished, ID: %s, Active: %d",
 				e.RequestID,
@@ -216,23 +567,40 @@ func (r *Renderer) Listen(ctx context.Context, conf BrowserConf) {
 			)
 			r.logger.Debug(msg)
 		case *network.EventLoadingFailed:
-			if !enabledIdleType([]string{"auto", "networkIdle"}, conf.IdleType) {
-				return
-			}
-			activeLen := r.idleCheck.remove(e.RequestID)
+			activeLen := state.idleCheck.remove(e.RequestID)
 			msg := fmt.Sprintf(
 				"Type: network.EventLoadingFailed, ID: %s, Active: %d",
 				e.RequestID,
 				activeLen,
 			)
 			r.logger.Debug(msg)
+		case *runtime.EventConsoleAPICalled:
+			state.console.addMessage(e)
+		case *runtime.EventExceptionThrown:
+			state.console.addException(e)
+		case *fetch.EventRequestPaused:
+			if failed := state.interceptor.handle(ctx, e, r.logger); failed && e.NetworkID != "" {
+				// stop counting failed request without waiting for its loading failed event
+				if activeLen, ok := state.idleCheck.untrack(e.NetworkID); ok {
+					r.logger.Debug(fmt.Sprintf(
+						"Type: fetch.EventRequestPaused failed, ID: %s, Active: %d",
+						e.NetworkID,
+						activeLen,
+					))
+				}
+			}
+		case *fetch.EventAuthRequired:
+			state.interceptor.handleAuth(ctx, e, r.logger)
 		case *page.EventLifecycleEvent:
-			if (e.Name == "load" || e.Name == "networkIdle") && e.FrameID == mainFrame {
-				if !enabledIdleType([]string{"auto", "networkIdle"}, conf.IdleType) {
-					return
+			if e.FrameID == mainFrame {
+				if e.Name == "init" {
+					state.lifecycle.reset()
 				}
-				if requestID, ok := r.idleCheck.byLoader[e.LoaderID]; ok {
-					activeLen := r.idleCheck.remove(requestID)
+				state.lifecycle.fire(e.Name)
+			}
+
+			if (e.Name == "load" || e.Name == "networkIdle") && e.FrameID == mainFrame {
+				if activeLen, ok := state.idleCheck.removeByLoader(e.LoaderID); ok {
 					msg := fmt.Sprintf(
 						"Type: EventLifecycle, Name: %s, Loader ID: %s, Active: %d",
 						e.Name,
@@ -244,82 +612,58 @@ func (r *Renderer) Listen(ctx context.Context, conf BrowserConf) {
 			}
 
 			if e.Name == "InteractiveTime" {
-				if !enabledIdleType([]string{"auto", "InteractiveTime"}, conf.IdleType) {
-					return
-				}
 				r.logger.Debug(fmt.Sprintf("Event name: %s, Frame ID: %s", e.Name, e.FrameID))
-				r.interactiveCheck.done <- struct{}{} // cancel the context when InteractiveTime is met
+				state.interactiveCheck.signal() // cancel the context when InteractiveTime is met
 			}
 		}
 	})
 }
 
-// waitFor listens for events in chromedp and stop loading as soon as given event is matched
-// or timeout is reached.
-// Two events are currently supported: networkIdle and InteractiveTime.
-func (r *Renderer) waitFor(ctx context.Context, opts chromedpOption) error {
+// waitFor waits for the wait condition of browser config to be met or timeout is
+// reached. Condition is built from the idle type settings if WaitCondition is not set.
+func (r *Renderer) waitFor(ctx context.Context, opts chromedpOption, state *renderState) error {
 	browserConf := opts.readBrowserConf()
 	rendererConf := opts.readRendererConf()
 
 	cctx, cancel := context.WithTimeout(ctx, time.Duration(rendererConf.Timeout)*time.Second)
 	defer cancel()
-
-	// Initial arm (in case we’re already quiet)
-	if enabledIdleType([]string{"auto", "networkIdle"}, browserConf.IdleType) {
-		ticker := time.NewTicker(500 * time.Millisecond)
-		defer ticker.Stop()
-		go func() {
-			for {
-				select {
-				case <-ticker.C:
-					r.idleCheck.promoteLongPoll()
-				case <-cctx.Done():
-					return
-				}
-			}
-		}()
-
-		r.idleCheck.mu.Lock()
-		r.idleCheck.startOrResetTimer()
-		r.idleCheck.mu.Unlock()
+	defer state.idleCheck.stop()
+
+	condition := idleTypeCondition(browserConf)
+	endedBy := condition.String()
+	err := condition.Wait(withWaitContext(cctx, waitContext{
+		renderer: r,
+		state:    state,
+		endedBy:  &endedBy,
+	}))
+	if err == nil {
+		state.waitEndedBy = endedBy
+		r.logger.Debug(fmt.Sprintf("waitFor: %s done", endedBy))
+		return nil
 	}
 
-	select {
-	case <-r.idleCheck.done:
-		r.idleCheck.mu.Lock()
-		r.idleCheck.stopped = true
-		if r.idleCheck.idleTimer != nil {
-			_ = r.idleCheck.idleTimer.Stop() // stop the timer if it is running
-		}
-		r.idleCheck.mu.Unlock()
-		r.logger.Debug("waitFor: networkIdle done")
-		return nil
-	case <-r.interactiveCheck.done:
-		r.idleCheck.mu.Lock()
-		r.idleCheck.stopped = true
-		if r.idleCheck.idleTimer != nil {
-			_ = r.idleCheck.idleTimer.Stop() // stop the timer if it is running
-		}
-		r.idleCheck.mu.Unlock()
-		r.logger.Debug("waitFor: InteractiveTime done")
-		return nil
-	case <-cctx.Done():
-		r.idleCheck.mu.Lock()
-		r.idleCheck.stopped = true
-		if r.idleCheck.idleTimer != nil {
-			_ = r.idleCheck.idleTimer.Stop() // stop the timer if it is running
+	// ctx is done by caller cancellation or deadline, stop rendering
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("waitFor err: %w", err)
+	}
+	if err := cctx.Err(); errors.Is(err, context.DeadlineExceeded) {
+		state.waitEndedBy = WaitEndedByTimeout
+		timeoutErr := &WaitTimeoutError{
+			Timeout: time.Duration(rendererConf.Timeout) * time.Second,
+			Idle:    state.idleCheck.report(),
+			Err:     err,
 		}
-		r.idleCheck.mu.Unlock()
-		if err := cctx.Err(); errors.Is(err, context.DeadlineExceeded) {
-			r.logger.Debug(fmt.Sprintf("Remains active network request: %+v", r.idleCheck.active))
-			for _, info := range r.idleCheck.active {
-				r.logger.Debug(fmt.Sprintf("Remain active info: %+v", info))
-			}
-			return fmt.Errorf("waitFor err: %w", err)
+		for _, info := range state.idleCheck.activeRequests() {
+			r.logger.Debug(fmt.Sprintf("Remain active info: %+v", info))
+			timeoutErr.Active = append(timeoutErr.Active, ActiveRequest{
+				URL:          info.url,
+				ResourceType: info.resourceType.String(),
+				Start:        info.start,
+			})
 		}
-		r.logger.Debug("waitFor: cctx done")
-		return nil
+		return timeoutErr
 	}
+	return fmt.Errorf("waitFor err: %w", err)
 }
 
 func setChromeOpts(opts chromedpOption) []chromedp.ExecAllocatorOption {
@@ -344,6 +688,14 @@ func setChromeOpts(opts chromedpOption) []chromedp.ExecAllocatorOption {
 	if rendererConf.UserAgent != "" {
 		chromeOpts = append(chromeOpts, chromedp.UserAgent(rendererConf.UserAgent))
 	}
+	if browserConf.Proxy != "" {
+		chromeOpts = append(chromeOpts, chromedp.ProxyServer(proxyServer(browserConf.Proxy)))
+		if len(browserConf.ProxyBypass) > 0 {
+			chromeOpts = append(chromeOpts,
+				chromedp.Flag("proxy-bypass-list", proxyBypassList(browserConf.ProxyBypass)),
+			)
+		}
+	}
 
 	chromeOpts = append(
 		chromeOpts,
@@ -360,10 +712,3 @@ func setChromeOpts(opts chromedpOption) []chromedp.ExecAllocatorOption {
 func cmToInch(cm float64) float64 {
 	return math.Round((cm/2.54)*100) / 100
 }
-
-func enabledIdleType(idleTypes []string, idleType string) bool {
-	if slices.Contains(idleTypes, idleType) {
-		return true
-	}
-	return false
-}
@@ -1,9 +1,24 @@
 package renderer_test
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/png"
 	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
 
+	"github.com/chromedp/cdproto/network"
 	"github.com/liuminhaw/renderer"
 )
 
@@ -27,6 +42,29 @@ func ExampleRenderer_RenderPage() {
 	f.Write(content)
 }
 
+func ExampleRenderer_RenderPageContext() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	r := renderer.NewRenderer(renderer.WithLogger(logger))
+	content, err := r.RenderPageContext(ctx, "https://www.example.com", nil)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	f, err := os.Create("result.html")
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	f.Write(content)
+}
+
 func ExampleRenderer_RenderPdf() {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 
@@ -47,3 +85,1223 @@ func ExampleRenderer_RenderPdf() {
 
 	f.Write(content)
 }
+
+func ExampleRenderer_RenderScreenshot() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	r := renderer.NewRenderer(renderer.WithLogger(logger))
+
+	opts := renderer.DefaultScreenshotOption
+	opts.FullPage = true
+	content, err := r.RenderScreenshot("https://www.example.com", &opts)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	f, err := os.Create("result.png")
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	f.Write(content)
+}
+
+func ExampleRenderer_RenderArtifacts() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	r := renderer.NewRenderer(renderer.WithLogger(logger))
+
+	res, err := r.RenderArtifacts(context.Background(), "https://www.example.com", &renderer.ArtifactOption{
+		BrowserOpts:  renderer.DefaultRendererOption.BrowserOpts,
+		RendererOpts: renderer.DefaultRendererConf,
+		HTML:         true,
+		Pdf:          &renderer.DefaultPdfOption,
+		Screenshot:   &renderer.DefaultScreenshotOption,
+	})
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	os.WriteFile("result.html", res.Artifacts.HTML, 0644)
+	os.WriteFile("result.pdf", res.Artifacts.Pdf, 0644)
+	os.WriteFile("result.png", res.Artifacts.Screenshot, 0644)
+}
+
+func ExampleAll() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	r := renderer.NewRenderer(renderer.WithLogger(logger))
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.WaitCondition = renderer.All(
+		renderer.NetworkIdle(),
+		renderer.Selector("#app", renderer.SelectorPresent),
+		renderer.SinceLoad(2*time.Second),
+	)
+	res, err := r.RenderPageResult(context.Background(), "https://www.example.com", &opts)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	logger.Info("rendered", slog.String("waitEndedBy", res.WaitEndedBy))
+}
+
+func ExampleNewBrowser() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	b := renderer.NewBrowser(
+		renderer.DefaultRendererOption.BrowserOpts,
+		renderer.DefaultRendererOption.Opts,
+	)
+	r := renderer.NewRenderer(renderer.WithLogger(logger), renderer.WithBrowser(b))
+	defer r.Close()
+
+	for _, url := range []string{"https://www.example.com", "https://www.example.org"} {
+		content, err := r.RenderPage(url, nil)
+		if err != nil {
+			logger.Error(err.Error())
+			continue
+		}
+		logger.Info("rendered", slog.String("url", url), slog.Int("size", len(content)))
+	}
+}
+
+// requireBrowser skips the test if no chrome / chromium executable is found
+func requireBrowser(t *testing.T) string {
+	t.Helper()
+	for _, name := range []string{
+		"headless_shell",
+		"headless-shell",
+		"chromium",
+		"chromium-browser",
+		"google-chrome",
+		"google-chrome-stable",
+		"chrome",
+	} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	t.Skip("chrome / chromium executable not found")
+	return ""
+}
+
+func TestRenderPageConcurrent(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// delay some pages so that renders overlap with different timing
+		if req.URL.Query().Get("slow") != "" {
+			time.Sleep(300 * time.Millisecond)
+		}
+		fmt.Fprintf(w, "<html><body><p id=\"page\">%s</p></body></html>", req.URL.Path)
+	}))
+	defer srv.Close()
+
+	r := renderer.NewRenderer(renderer.WithIdleCheck(200*time.Millisecond, 0))
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+	opts.Opts.Timeout = 20
+
+	const renders = 4
+	var wg sync.WaitGroup
+	errs := make(chan error, renders)
+	for i := 0; i < renders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path := fmt.Sprintf("/page-%d", i)
+			url := srv.URL + path
+			if i%2 == 0 {
+				url += "?slow=1"
+			}
+			content, err := r.RenderPage(url, &opts)
+			if err != nil {
+				errs <- fmt.Errorf("render %s: %w", path, err)
+				return
+			}
+			if !strings.Contains(string(content), path) {
+				errs <- fmt.Errorf("render %s: unexpected content %q", path, content)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestRenderPageContextCancel(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	// page that never finish loading
+	hang := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-hang:
+		case <-req.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(hang)
+
+	r := renderer.NewRenderer()
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(2*time.Second, cancel)
+
+	start := time.Now()
+	_, err := r.RenderPageContext(ctx, srv.URL, &opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("RenderPageContext error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("RenderPageContext returned %v after cancel", elapsed)
+	}
+}
+
+func TestRenderPageWithBrowser(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", req.URL.Path)
+	}))
+	defer srv.Close()
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+
+	b := renderer.NewBrowser(opts.BrowserOpts, opts.Opts)
+	r := renderer.NewRenderer(renderer.WithBrowser(b))
+
+	for _, path := range []string{"/first", "/second"} {
+		content, err := r.RenderPage(srv.URL+path, &opts)
+		if err != nil {
+			t.Fatalf("render %s: %v", path, err)
+		}
+		if !strings.Contains(string(content), path) {
+			t.Errorf("render %s: unexpected content %q", path, content)
+		}
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if _, err := r.RenderPage(srv.URL, &opts); !errors.Is(err, renderer.ErrBrowserClosed) {
+		t.Errorf("render after close error = %v, want %v", err, renderer.ErrBrowserClosed)
+	}
+}
+
+func TestRenderPageWithBrowserPool(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	var mu sync.Mutex
+	var active, maxActive int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		active++
+		maxActive = max(maxActive, active)
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", req.URL.Path)
+	}))
+	defer srv.Close()
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+
+	p := renderer.NewBrowserPool(
+		opts.BrowserOpts,
+		opts.Opts,
+		renderer.PoolConf{Size: 1, MaxTabs: 2, MaxRenders: 3},
+	)
+	r := renderer.NewRenderer(renderer.WithBrowserPool(p))
+	defer r.Close()
+
+	// renders beyond the tabs of the pool wait in queue, browser is restarted every
+	// 3 renders
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path := fmt.Sprintf("/page-%d", i)
+			content, err := r.RenderPageContext(context.Background(), srv.URL+path, &opts)
+			if err != nil {
+				t.Errorf("render %s: %v", path, err)
+				return
+			}
+			if !strings.Contains(string(content), path) {
+				t.Errorf("render %s: unexpected content %q", path, content)
+			}
+		}()
+	}
+	wg.Wait()
+
+	mu.Lock()
+	if maxActive > 2 {
+		t.Errorf("concurrent renders = %d, want at most MaxTabs 2", maxActive)
+	}
+	mu.Unlock()
+}
+
+// startRemoteBrowser starts browser with remote debugging port and returns its
+// http endpoint
+func startRemoteBrowser(t *testing.T, execPath string) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("find free port: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	cmd := exec.Command(execPath,
+		"--headless",
+		"--no-sandbox",
+		"--disable-dev-shm-usage",
+		fmt.Sprintf("--remote-debugging-port=%d", port),
+		fmt.Sprintf("--user-data-dir=%s", t.TempDir()),
+		"about:blank",
+	)
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start remote browser: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+
+	endpoint := fmt.Sprintf("http://127.0.0.1:%d", port)
+	for i := 0; i < 50; i++ {
+		resp, err := http.Get(endpoint + "/json/version")
+		if err == nil {
+			resp.Body.Close()
+			return endpoint
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("remote browser not ready")
+	return ""
+}
+
+func TestRenderPageRemote(t *testing.T) {
+	execPath := requireBrowser(t)
+	endpoint := startRemoteBrowser(t, execPath)
+
+	var mu sync.Mutex
+	var images int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/image.png" {
+			mu.Lock()
+			images++
+			mu.Unlock()
+			w.Header().Set("Content-Type", "image/png")
+			return
+		}
+		fmt.Fprintf(w, `<html><body><p>%s</p><img src="/image.png"></body></html>`, req.URL.Path)
+	}))
+	defer srv.Close()
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.RemoteURL = endpoint
+	defer func() {
+		// remote browser loads images, blocked as ImageLoad is false
+		mu.Lock()
+		defer mu.Unlock()
+		if images != 0 {
+			t.Errorf("images loaded = %d, want blocked without ImageLoad", images)
+		}
+	}()
+
+	t.Run("per render", func(t *testing.T) {
+		r := renderer.NewRenderer()
+		res, err := r.RenderPageResult(context.Background(), srv.URL+"/remote", &opts)
+		if err != nil {
+			t.Fatalf("render: %v", err)
+		}
+		if !strings.Contains(string(res.Content), "/remote") {
+			t.Errorf("unexpected content %q", res.Content)
+		}
+		if len(res.Blocked) != 1 || !strings.HasSuffix(res.Blocked[0].URL, "/image.png") {
+			t.Errorf("Blocked = %+v, want image", res.Blocked)
+		}
+	})
+
+	t.Run("long-lived", func(t *testing.T) {
+		r := renderer.NewRenderer(
+			renderer.WithBrowser(renderer.NewBrowser(opts.BrowserOpts, opts.Opts)),
+		)
+		defer r.Close()
+		for _, path := range []string{"/first", "/second"} {
+			content, err := r.RenderPage(srv.URL+path, &opts)
+			if err != nil {
+				t.Fatalf("render %s: %v", path, err)
+			}
+			if !strings.Contains(string(content), path) {
+				t.Errorf("render %s: unexpected content %q", path, content)
+			}
+		}
+	})
+}
+
+func TestRenderPageResult(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, "/missing", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Test", "renderer")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `<html><head><title>Not here</title><script src="/app.js"></script></head>
+			<body>missing</body></html>`)
+	})
+	mux.HandleFunc("/app.js", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript")
+		fmt.Fprint(w, "document.title = document.title")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+
+	r := renderer.NewRenderer()
+	res, err := r.RenderPageResult(context.Background(), srv.URL+"/old", &opts)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("StatusCode = %d, want %d", res.StatusCode, http.StatusNotFound)
+	}
+	if res.FinalURL != srv.URL+"/missing" {
+		t.Errorf("FinalURL = %q, want %q", res.FinalURL, srv.URL+"/missing")
+	}
+	if len(res.Redirects) != 1 || res.Redirects[0].StatusCode != http.StatusMovedPermanently {
+		t.Errorf("Redirects = %+v, want one 301 redirect", res.Redirects)
+	}
+	if got := res.Headers.Get("X-Test"); got != "renderer" {
+		t.Errorf("X-Test header = %q, want %q", got, "renderer")
+	}
+	if res.Title != "Not here" {
+		t.Errorf("Title = %q, want %q", res.Title, "Not here")
+	}
+	if res.WaitEndedBy == "" || res.Timings.Total <= 0 {
+		t.Errorf("WaitEndedBy = %q, Timings = %+v", res.WaitEndedBy, res.Timings)
+	}
+	if res.Idle == nil || len(res.Idle.Requests) == 0 || len(res.Idle.Timeline) == 0 {
+		t.Errorf("Idle = %+v, want requests and timeline", res.Idle)
+	}
+	if len(res.Attempts) != 1 || res.Attempts[0].Err != nil {
+		t.Errorf("Attempts = %+v, want one successful attempt", res.Attempts)
+	}
+}
+
+func TestRenderPageNavigationError(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	// closed server refuses connections
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+
+	r := renderer.NewRenderer()
+	_, err := r.RenderPage(srv.URL, &opts)
+	if !errors.Is(err, renderer.ErrNavigation) {
+		t.Fatalf("render error = %v, want %v", err, renderer.ErrNavigation)
+	}
+	var navErr *renderer.NavigationError
+	if !errors.As(err, &navErr) || !strings.HasPrefix(navErr.Code, "net::ERR_") {
+		t.Errorf("navigation error code = %+v, want net::ERR_*", navErr)
+	}
+}
+
+func TestRenderScreenshot(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `<html><body style="margin:0">
+			<div id="box" style="width:120px;height:80px;background:red"></div>
+			<div style="height:3000px"></div>
+		</body></html>`)
+	}))
+	defer srv.Close()
+
+	r := renderer.NewRenderer()
+	base := renderer.DefaultScreenshotOption
+	base.BrowserOpts.BrowserExecPath = execPath
+	base.BrowserOpts.Container = true
+	base.RendererOpts.WindowWidth = 800
+	base.RendererOpts.WindowHeight = 600
+
+	tests := []struct {
+		name   string
+		modify func(*renderer.ScreenshotOption)
+		width  int
+		height int
+	}{
+		{"viewport", func(o *renderer.ScreenshotOption) {}, 800, 600},
+		{"full page", func(o *renderer.ScreenshotOption) { o.FullPage = true }, 800, 3080},
+		{"selector", func(o *renderer.ScreenshotOption) { o.Selector = "#box" }, 120, 80},
+		{"selector scaled", func(o *renderer.ScreenshotOption) {
+			o.Selector = "#box"
+			o.Scale = 2
+		}, 240, 160},
+		{"clip", func(o *renderer.ScreenshotOption) {
+			o.Clip = &renderer.ClipRect{X: 10, Y: 10, Width: 50, Height: 40}
+		}, 50, 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := base
+			tt.modify(&opts)
+
+			content, err := r.RenderScreenshot(srv.URL, &opts)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			img, err := png.DecodeConfig(bytes.NewReader(content))
+			if err != nil {
+				t.Fatalf("decode png: %v", err)
+			}
+			if img.Width != tt.width || img.Height != tt.height {
+				t.Errorf("image size = %dx%d, want %dx%d", img.Width, img.Height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestRenderArtifacts(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	var requests int
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/" {
+			http.NotFound(w, req)
+			return
+		}
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		fmt.Fprint(w, "<html><body><p>artifact text</p></body></html>")
+	}))
+	defer srv.Close()
+
+	opts := renderer.ArtifactOption{
+		BrowserOpts:  renderer.DefaultRendererOption.BrowserOpts,
+		RendererOpts: renderer.DefaultRendererConf,
+		HTML:         true,
+		Text:         true,
+		MHTML:        true,
+		Pdf:          &renderer.DefaultPdfOption,
+		Screenshot:   &renderer.DefaultScreenshotOption,
+	}
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+
+	r := renderer.NewRenderer()
+	res, err := r.RenderArtifacts(context.Background(), srv.URL, &opts)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	mu.Lock()
+	if requests != 1 {
+		t.Errorf("page requested %d times, want 1", requests)
+	}
+	mu.Unlock()
+	if !strings.Contains(string(res.Artifacts.HTML), "artifact text") {
+		t.Errorf("HTML = %q", res.Artifacts.HTML)
+	}
+	if strings.TrimSpace(string(res.Artifacts.Text)) != "artifact text" {
+		t.Errorf("Text = %q", res.Artifacts.Text)
+	}
+	if !strings.Contains(string(res.Artifacts.MHTML), "MIME-Version") {
+		t.Errorf("MHTML missing MIME header")
+	}
+	if !bytes.HasPrefix(res.Artifacts.Pdf, []byte("%PDF")) {
+		t.Errorf("Pdf missing pdf header")
+	}
+	if _, err := png.DecodeConfig(bytes.NewReader(res.Artifacts.Screenshot)); err != nil {
+		t.Errorf("Screenshot decode png: %v", err)
+	}
+}
+
+func TestRenderPageWaitSelectorAndExpression(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	// page keeps polling so it never becomes network idle, content is ready later
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/poll" {
+			time.Sleep(200 * time.Millisecond)
+			return
+		}
+		fmt.Fprint(w, `<html><body><script>
+			setInterval(() => fetch("/poll"), 100);
+			setTimeout(() => {
+				const el = document.createElement("div");
+				el.id = "app";
+				el.textContent = "ready";
+				document.body.appendChild(el);
+				window.prerenderReady = true;
+			}, 500);
+		</script></body></html>`)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		conf renderer.BrowserConf
+	}{
+		{"selector", renderer.BrowserConf{
+			IdleType:          "selector",
+			WaitSelector:      "#app",
+			WaitSelectorState: renderer.SelectorVisible,
+		}},
+		{"expression", renderer.BrowserConf{
+			IdleType:       "expression",
+			WaitExpression: "window.prerenderReady === true",
+		}},
+	}
+
+	r := renderer.NewRenderer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := renderer.DefaultRendererOption
+			opts.BrowserOpts = tt.conf
+			opts.BrowserOpts.BrowserExecPath = execPath
+			opts.BrowserOpts.Container = true
+			opts.Opts.Timeout = 10
+
+			res, err := r.RenderPageResult(context.Background(), srv.URL, &opts)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if res.WaitEndedBy != tt.conf.IdleType {
+				t.Errorf("WaitEndedBy = %q, want %q", res.WaitEndedBy, tt.conf.IdleType)
+			}
+			if !strings.Contains(string(res.Content), `<div id="app">ready</div>`) {
+				t.Errorf("content missing ready element: %q", res.Content)
+			}
+		})
+	}
+}
+
+func TestRenderPageLifecycleIdleTypes(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	// page keeps polling so it never becomes network idle
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/poll" {
+			time.Sleep(200 * time.Millisecond)
+			return
+		}
+		fmt.Fprint(w, `<html><body><h1>Painted</h1><script>
+			setInterval(() => fetch("/poll"), 100);
+		</script></body></html>`)
+	}))
+	defer srv.Close()
+
+	idleTypes := []string{"DOMContentLoaded", "load", "firstContentfulPaint", "largestContentfulPaint"}
+
+	r := renderer.NewRenderer()
+	for _, idleType := range idleTypes {
+		t.Run(idleType, func(t *testing.T) {
+			opts := renderer.DefaultRendererOption
+			opts.BrowserOpts.IdleType = idleType
+			opts.BrowserOpts.BrowserExecPath = execPath
+			opts.BrowserOpts.Container = true
+			opts.Opts.Timeout = 10
+
+			res, err := r.RenderPageResult(context.Background(), srv.URL, &opts)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			if res.WaitEndedBy != idleType {
+				t.Errorf("WaitEndedBy = %q, want %q", res.WaitEndedBy, idleType)
+			}
+			if !strings.Contains(string(res.Content), "<h1>Painted</h1>") {
+				t.Errorf("content missing heading: %q", res.Content)
+			}
+		})
+	}
+}
+
+func TestRenderPageDOMQuiet(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	// page keeps mutating dom for a while after load, network is idle all along
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `<html><body><ul id="list"></ul><script>
+			let count = 0;
+			const timer = setInterval(() => {
+				const li = document.createElement("li");
+				li.textContent = "item " + ++count;
+				document.getElementById("list").appendChild(li);
+				if (count === 10) {
+					clearInterval(timer);
+				}
+			}, 100);
+		</script></body></html>`)
+	}))
+	defer srv.Close()
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.WaitCondition = renderer.All(
+		renderer.NetworkIdle(),
+		renderer.DOMQuiet(300*time.Millisecond, 2),
+	)
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+	opts.Opts.Timeout = 10
+
+	r := renderer.NewRenderer()
+	res, err := r.RenderPageResult(context.Background(), srv.URL, &opts)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !strings.Contains(string(res.Content), "<li>item 10</li>") {
+		t.Errorf("content missing last item: %q", res.Content)
+	}
+}
+
+func TestRenderPageBlockRequests(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	var mu sync.Mutex
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		requested = append(requested, req.URL.Path)
+		mu.Unlock()
+		switch req.URL.Path {
+		case "/style.css":
+			w.Header().Set("Content-Type", "text/css")
+			fmt.Fprint(w, "body { color: red; }")
+		case "/analytics.js":
+			time.Sleep(5 * time.Second)
+		case "/app.js":
+			w.Header().Set("Content-Type", "text/javascript")
+			fmt.Fprint(w, "document.body.dataset.app = 'loaded'")
+		case "/tracker.js":
+			http.Redirect(w, req, "/analytics.js", http.StatusFound)
+		default:
+			fmt.Fprint(w, `<html><head>
+				<link rel="stylesheet" href="/style.css">
+				<script src="/analytics.js" async></script>
+				<script src="/tracker.js" async></script>
+			</head><body>blocked<script src="/app.js"></script></body></html>`)
+		}
+	}))
+	defer srv.Close()
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+	opts.Opts.BlockResourceTypes = []network.ResourceType{network.ResourceTypeStylesheet}
+	opts.Opts.BlockURLs = []renderer.URLPattern{renderer.Glob("*/analytics.js")}
+
+	r := renderer.NewRenderer()
+	res, err := r.RenderPageResult(context.Background(), srv.URL, &opts)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if res.Timings.Wait > 3*time.Second {
+		t.Errorf("Wait = %v, blocked request counted by network idle", res.Timings.Wait)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, path := range requested {
+		if path == "/style.css" || path == "/analytics.js" {
+			t.Errorf("blocked request %s reached server", path)
+		}
+	}
+	var appTracked bool
+	for _, req := range res.Idle.Requests {
+		if strings.HasSuffix(req.URL, "/style.css") || strings.HasSuffix(req.URL, "/analytics.js") {
+			t.Errorf("blocked request tracked by network idle check: %+v", req)
+		}
+		if strings.HasSuffix(req.URL, "/tracker.js") && req.End.IsZero() {
+			t.Errorf("request redirected to blocked url still active: %+v", req)
+		}
+		appTracked = appTracked || strings.HasSuffix(req.URL, "/app.js")
+	}
+	if !appTracked {
+		t.Errorf("Idle.Requests = %+v, want /app.js tracked", res.Idle.Requests)
+	}
+	if len(res.Blocked) != 3 {
+		t.Errorf("Blocked = %+v, want stylesheet, analytics and redirected tracker requests", res.Blocked)
+	}
+}
+
+func TestRenderPageFilterList(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/ads/slow.js":
+			time.Sleep(5 * time.Second)
+		case "/app.js":
+			w.Header().Set("Content-Type", "text/javascript")
+			fmt.Fprint(w, "document.body.dataset.app = 'loaded'")
+		default:
+			fmt.Fprint(w, `<html><head>
+				<script src="/ads/slow.js" async></script>
+			</head><body>filtered<script src="/app.js"></script></body></html>`)
+		}
+	}))
+	defer srv.Close()
+
+	list, err := renderer.ParseFilterList(strings.NewReader("/ads/*\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+	opts.BrowserOpts.IdleType = "networkIdle"
+	opts.Opts.FilterLists = []*renderer.FilterList{list}
+
+	r := renderer.NewRenderer()
+	res, err := r.RenderPageResult(context.Background(), srv.URL, &opts)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if res.Timings.Wait > 3*time.Second {
+		t.Errorf("Wait = %v, filtered request counted by network idle", res.Timings.Wait)
+	}
+	if len(res.Blocked) != 1 || res.Blocked[0].Rule != "/ads/*" {
+		t.Errorf("Blocked = %+v, want request of /ads/slow.js", res.Blocked)
+	}
+	var appTracked bool
+	for _, req := range res.Idle.Requests {
+		if strings.Contains(req.URL, "/ads/") {
+			t.Errorf("filtered request tracked by network idle check: %+v", req)
+		}
+		appTracked = appTracked || strings.HasSuffix(req.URL, "/app.js")
+	}
+	if !appTracked {
+		t.Errorf("Idle.Requests = %+v, want /app.js tracked", res.Idle.Requests)
+	}
+}
+
+func TestRenderPageHeadersCookiesBasicAuth(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		user, pass, ok := req.BasicAuth()
+		if !ok || user != "staging" || pass != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="staging"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mu.Lock()
+		seen["X-Env"] = req.Header.Get("X-Env")
+		if cookie, err := req.Cookie("session"); err == nil {
+			seen["session"] = cookie.Value
+		}
+		mu.Unlock()
+		fmt.Fprint(w, "<html><body>logged in</body></html>")
+	}))
+	defer srv.Close()
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+	opts.Opts.Headers = map[string]string{"X-Env": "staging"}
+	opts.Opts.HeadersOriginOnly = true
+	opts.Opts.Cookies = []*http.Cookie{{Name: "session", Value: "abc"}}
+	opts.Opts.BasicAuth = &renderer.BasicAuth{Username: "staging", Password: "secret"}
+
+	r := renderer.NewRenderer()
+	res, err := r.RenderPageResult(context.Background(), srv.URL, &opts)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if res.StatusCode != http.StatusOK || !strings.Contains(string(res.Content), "logged in") {
+		t.Errorf("StatusCode = %d, content = %q", res.StatusCode, res.Content)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if seen["X-Env"] != "staging" || seen["session"] != "abc" {
+		t.Errorf("server seen = %v, want X-Env header and session cookie", seen)
+	}
+}
+
+// startProxy starts http proxy which requires basic auth of user and responds every
+// request with a page naming the proxy.
+func startProxy(t *testing.T, name, user, pass string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		auth := req.Header.Get("Proxy-Authorization")
+		req.Header.Set("Authorization", auth)
+		if u, p, ok := req.BasicAuth(); !ok || u != user || p != pass {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="proxy"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		fmt.Fprintf(w, "<html><body>via %s to %s</body></html>", name, req.Host)
+	}))
+	t.Cleanup(srv.Close)
+	return strings.Replace(srv.URL, "http://", "http://"+user+":"+pass+"@", 1)
+}
+
+func TestRenderPageProxy(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	proxyA := startProxy(t, "proxy-a", "alice", "secret-a")
+	proxyB := startProxy(t, "proxy-b", "bob", "secret-b")
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+	opts.BrowserOpts.Proxy = proxyA
+
+	r := renderer.NewRenderer()
+	content, err := r.RenderPageContext(context.Background(), "http://renderer.test/", &opts)
+	if err != nil {
+		t.Fatalf("render with launched proxy: %v", err)
+	}
+	if !strings.Contains(string(content), "via proxy-a to renderer.test") {
+		t.Errorf("content = %q, want page through proxy-a", content)
+	}
+
+	// per browser context proxies of a long-lived browser
+	b := renderer.NewBrowser(
+		renderer.BrowserConf{BrowserExecPath: execPath, Container: true},
+		renderer.DefaultRendererConf,
+	)
+	rb := renderer.NewRenderer(renderer.WithBrowser(b))
+	defer rb.Close()
+
+	var wg sync.WaitGroup
+	for _, proxy := range []struct{ name, url string }{{"proxy-a", proxyA}, {"proxy-b", proxyB}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			opts := renderer.DefaultRendererOption
+			opts.BrowserOpts.Proxy = proxy.url
+			content, err := rb.RenderPageContext(context.Background(), "http://renderer.test/", &opts)
+			if err != nil {
+				t.Errorf("render with %s: %v", proxy.name, err)
+				return
+			}
+			if !strings.Contains(string(content), "via "+proxy.name) {
+				t.Errorf("content = %q, want page through %s", content, proxy.name)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRenderPageMock(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `<html><body><ul id="items"></ul><p id="flaky"></p><script>
+			fetch("https://api.third-party.test/items")
+				.then((res) => res.json())
+				.then((data) => {
+					document.getElementById("items").innerHTML = data.items.map((i) => "<li>" + i + "</li>").join("");
+				});
+			fetch("https://api.third-party.test/flaky")
+				.catch(() => { document.getElementById("flaky").textContent = "unavailable"; });
+		</script></body></html>`)
+	}))
+	defer srv.Close()
+
+	const delay = 800 * time.Millisecond
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+	opts.BrowserOpts.IdleType = "networkIdle"
+	opts.Opts.Mocks = []renderer.MockRule{
+		{
+			URL:     renderer.Glob("https://api.third-party.test/items"),
+			Headers: map[string]string{"Content-Type": "application/json", "Access-Control-Allow-Origin": "*"},
+			Body:    []byte(`{"items":["mocked"]}`),
+			Delay:   delay,
+		},
+		{URL: renderer.Glob("*/flaky"), Error: network.ErrorReasonConnectionRefused},
+	}
+
+	r := renderer.NewRenderer()
+	res, err := r.RenderPageResult(context.Background(), srv.URL, &opts)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !strings.Contains(string(res.Content), "<li>mocked</li>") {
+		t.Errorf("content missing mocked item: %q", res.Content)
+	}
+	if !strings.Contains(string(res.Content), `<p id="flaky">unavailable</p>`) {
+		t.Errorf("content missing failed request handling: %q", res.Content)
+	}
+	if res.Timings.Wait < delay {
+		t.Errorf("Wait = %v, want network idle after mocked request delay %v", res.Timings.Wait, delay)
+	}
+}
+
+func TestRenderPageHAR(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/data.json":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"ok":true}`)
+		case "/large.txt":
+			fmt.Fprint(w, strings.Repeat("x", 4096))
+		default:
+			fmt.Fprint(w, `<html><head><title>har</title></head><body><script>
+				fetch("/data.json?q=1");
+				fetch("/large.txt");
+			</script></body></html>`)
+		}
+	}))
+	defer srv.Close()
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+	opts.BrowserOpts.IdleType = "networkIdle"
+	opts.Opts.RecordHAR = true
+	opts.Opts.HARBodyLimit = 1024
+
+	r := renderer.NewRenderer()
+	res, err := r.RenderPageResult(context.Background(), srv.URL, &opts)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if res.HAR == nil {
+		t.Fatal("HAR is not recorded")
+	}
+	if len(res.HAR.Log.Pages) != 1 || res.HAR.Log.Pages[0].Title != "har" {
+		t.Errorf("pages = %+v, want page of title har", res.HAR.Log.Pages)
+	}
+
+	entries := make(map[string]renderer.HAREntry)
+	for _, entry := range res.HAR.Log.Entries {
+		entries[strings.TrimPrefix(entry.Request.URL, srv.URL)] = entry
+	}
+	if doc, ok := entries["/"]; !ok || doc.Response.Status != http.StatusOK || !strings.Contains(doc.Response.Content.Text, "<title>har</title>") {
+		t.Errorf("document entry = %+v", doc)
+	}
+	if data, ok := entries["/data.json?q=1"]; !ok || data.Response.Content.Text != `{"ok":true}` || len(data.Request.QueryString) != 1 {
+		t.Errorf("data entry = %+v", data)
+	}
+	if large, ok := entries["/large.txt"]; !ok || large.Response.Content.Text != "" || large.Response.Content.Comment == "" {
+		t.Errorf("large entry = %+v, want body over limit skipped", large)
+	}
+
+	name := filepath.Join(t.TempDir(), "render.har")
+	if err := res.HAR.WriteFile(name); err != nil {
+		t.Errorf("WriteFile: %v", err)
+	}
+}
+
+func TestRenderPageReplay(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/items":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"items":["%s"]}`, time.Now().Format(time.RFC3339Nano))
+		default:
+			fmt.Fprint(w, `<html><body><ul id="items"></ul><script>
+				fetch("/items", {method: "POST", body: "q=1"})
+					.then((res) => res.json())
+					.then((data) => {
+						document.getElementById("items").innerHTML = data.items.map((i) => "<li>" + i + "</li>").join("");
+					});
+				fetch("/unrecorded").catch(() => {});
+			</script></body></html>`)
+		}
+	}))
+	url := srv.URL
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+	opts.BrowserOpts.IdleType = "networkIdle"
+	opts.Opts.RecordHAR = true
+	opts.Opts.HARBodyLimit = 1 << 20
+
+	r := renderer.NewRenderer()
+	recorded, err := r.RenderPageResult(context.Background(), url, &opts)
+	if err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	srv.Close()
+
+	name := filepath.Join(t.TempDir(), "render.har")
+	if err := recorded.HAR.WriteFile(name); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	har, err := renderer.LoadHAR(name)
+	if err != nil {
+		t.Fatalf("LoadHAR: %v", err)
+	}
+	// drop a recorded response, its request is reported as missed by replay
+	for i, entry := range har.Log.Entries {
+		if strings.HasSuffix(entry.Request.URL, "/unrecorded") {
+			har.Log.Entries[i].Response.Status = 0
+		}
+	}
+
+	opts.Opts.RecordHAR = false
+	opts.Opts.Replay = har
+	opts.Opts.ReplayMatchBody = true
+	replayed, err := r.RenderPageResult(context.Background(), url, &opts)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if !bytes.Equal(replayed.Content, recorded.Content) {
+		t.Errorf("replayed content = %q, want recorded %q", replayed.Content, recorded.Content)
+	}
+	if len(replayed.ReplayMisses) != 1 || !strings.HasSuffix(replayed.ReplayMisses[0], "/unrecorded") {
+		t.Errorf("ReplayMisses = %v, want unrecorded request", replayed.ReplayMisses)
+	}
+}
+
+func TestRenderPageConsole(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `<html><body><script>
+			console.log("rendering", 1);
+			console.error("missing config");
+			setTimeout(() => { undefinedFunction(); }, 0);
+		</script></body></html>`)
+	}))
+	defer srv.Close()
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+	opts.BrowserOpts.IdleType = "load"
+
+	r := renderer.NewRenderer()
+	res, err := r.RenderPageResult(context.Background(), srv.URL, &opts)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	levels := make(map[string]string)
+	for _, msg := range res.Console {
+		levels[msg.Level] = msg.Text
+	}
+	if levels["log"] != "rendering 1" || levels["error"] != "missing config" {
+		t.Errorf("Console = %+v, want log and error messages", res.Console)
+	}
+	if len(res.Exceptions) != 1 || !strings.Contains(res.Exceptions[0].Text, "undefinedFunction") {
+		t.Errorf("Exceptions = %+v, want ReferenceError of undefinedFunction", res.Exceptions)
+	}
+
+	opts.Opts.FailOnJSError = true
+	res, err = r.RenderPageResult(context.Background(), srv.URL, &opts)
+	var jsErr *renderer.JSError
+	if !errors.As(err, &jsErr) || len(jsErr.Errors) != 1 {
+		t.Errorf("render err = %v, want JSError with console error", err)
+	}
+	if res == nil || len(res.Content) == 0 {
+		t.Error("result with content is not returned with JSError")
+	}
+}
+
+func TestRenderPageResponsePolicy(t *testing.T) {
+	execPath := requireBrowser(t)
+
+	// other host is the same loopback reached as localhost, not in allowed hosts
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, "<html><body>other</body></html>")
+	}))
+	defer other.Close()
+	otherURL := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, "<html><body>internal error</body></html>")
+		case "/data.json":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"ok":true}`)
+		case "/away":
+			http.Redirect(w, req, otherURL+"/landing", http.StatusFound)
+		default:
+			fmt.Fprint(w, "<html><body>ok</body></html>")
+		}
+	}))
+	defer srv.Close()
+
+	opts := renderer.DefaultRendererOption
+	opts.BrowserOpts.BrowserExecPath = execPath
+	opts.BrowserOpts.Container = true
+	opts.BrowserOpts.IdleType = "load"
+	opts.Opts.FailStatus = []renderer.StatusRange{{Min: 500, Max: 599}}
+	opts.Opts.RequireHTML = true
+	opts.Opts.AllowedHosts = []string{"127.0.0.1"}
+
+	r := renderer.NewRenderer()
+	tests := []struct {
+		path   string
+		reason string
+	}{
+		{"/", ""},
+		{"/error", renderer.ResponseErrorStatus},
+		{"/data.json", renderer.ResponseErrorContentType},
+		{"/away", renderer.ResponseErrorHost},
+	}
+	for _, tt := range tests {
+		_, err := r.RenderPageResult(context.Background(), srv.URL+tt.path, &opts)
+		if tt.reason == "" {
+			if err != nil {
+				t.Errorf("render %s: %v", tt.path, err)
+			}
+			continue
+		}
+		var respErr *renderer.ResponseError
+		if !errors.As(err, &respErr) || respErr.Reason != tt.reason {
+			t.Errorf("render %s err = %v, want ResponseError of %s", tt.path, err, tt.reason)
+			continue
+		}
+		if tt.reason == renderer.ResponseErrorStatus &&
+			(respErr.StatusCode != 500 || !strings.Contains(string(respErr.Body), "internal error")) {
+			t.Errorf("render %s err = %+v, want status 500 with body", tt.path, respErr)
+		}
+		if tt.reason == renderer.ResponseErrorHost && respErr.URL != otherURL+"/landing" {
+			t.Errorf("render %s err url = %s, want %s/landing", tt.path, respErr.URL, otherURL)
+		}
+	}
+}
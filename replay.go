@@ -0,0 +1,134 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// replayDropHeaders are response headers not replayed, recorded body is already
+// decoded and its length may differ from the original response
+var replayDropHeaders = []string{"Content-Encoding", "Content-Length", "Transfer-Encoding"}
+
+// replay serves requests from entries of a recorded HAR. Entries of the same request
+// are served in recorded order, the last one is repeated once all are served.
+type replay struct {
+	matchBody bool
+	entries   map[string][]*HAREntry // entries with response by method and url
+
+	mu     sync.Mutex
+	served map[string]int // times served by request key
+}
+
+func newReplay(har *HAR, matchBody bool) *replay {
+	p := &replay{
+		matchBody: matchBody,
+		entries:   make(map[string][]*HAREntry),
+		served:    make(map[string]int),
+	}
+	for i := range har.Log.Entries {
+		entry := &har.Log.Entries[i]
+		if entry.Response.Status == 0 {
+			// failed or unfinished when recorded
+			continue
+		}
+		key := replayKey(entry.Request.Method, entry.Request.URL)
+		p.entries[key] = append(p.entries[key], entry)
+	}
+	return p
+}
+
+// match returns the entry to serve the request, false if the request is not recorded
+func (p *replay) match(method, rawURL string, body []byte) (*HAREntry, bool) {
+	key := replayKey(method, rawURL)
+	candidates := p.entries[key]
+	if p.matchBody {
+		var matched []*HAREntry
+		for _, entry := range candidates {
+			if bytes.Equal(entryBody(entry), body) {
+				matched = append(matched, entry)
+			}
+		}
+		candidates = matched
+		key += "\n" + string(body)
+	}
+	if len(candidates) == 0 {
+		return nil, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := p.served[key]
+	p.served[key] = n + 1
+	return candidates[min(n, len(candidates)-1)], true
+}
+
+// replayKey returns key of request with method and url without fragment
+func replayKey(method, rawURL string) string {
+	rawURL, _, _ = strings.Cut(rawURL, "#")
+	return strings.ToUpper(method) + " " + rawURL
+}
+
+// entryBody returns request body of the recorded entry
+func entryBody(entry *HAREntry) []byte {
+	if entry.Request.PostData == nil {
+		return nil
+	}
+	return []byte(entry.Request.PostData.Text)
+}
+
+// replayResponse returns params fulfilling the paused request with recorded response
+func replayResponse(id fetch.RequestID, entry *HAREntry) *fetch.FulfillRequestParams {
+	resp := entry.Response
+	headers := make([]*fetch.HeaderEntry, 0, len(resp.Headers))
+	for _, h := range resp.Headers {
+		drop := false
+		for _, name := range replayDropHeaders {
+			drop = drop || strings.EqualFold(h.Name, name)
+		}
+		if !drop {
+			headers = append(headers, &fetch.HeaderEntry{Name: h.Name, Value: h.Value})
+		}
+	}
+
+	body := []byte(resp.Content.Text)
+	if resp.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(resp.Content.Text)
+		if err == nil {
+			body = decoded
+		}
+	}
+
+	params := fetch.FulfillRequest(id, int64(resp.Status)).WithResponseHeaders(headers)
+	if resp.StatusText != "" {
+		params = params.WithResponsePhrase(resp.StatusText)
+	}
+	return params.WithBody(base64.StdEncoding.EncodeToString(body))
+}
+
+// replayHandler fulfils every request with its recorded response, requests not
+// recorded are failed or responded with 404 if ReplayNotFound is set
+func (i *interceptor) replayHandler(
+	e *fetch.EventRequestPaused,
+	_ *fetch.ContinueRequestParams,
+) chromedp.Action {
+	if entry, ok := i.replay.match(e.Request.Method, e.Request.URL, requestBody(e.Request)); ok {
+		return replayResponse(e.RequestID, entry)
+	}
+
+	i.mu.Lock()
+	i.unmatched = append(i.unmatched, e.Request.URL)
+	i.mu.Unlock()
+	if i.conf.ReplayNotFound {
+		return fetch.FulfillRequest(e.RequestID, http.StatusNotFound).
+			WithResponsePhrase(http.StatusText(http.StatusNotFound)).
+			WithBody("")
+	}
+	return fetch.FailRequest(e.RequestID, network.ErrorReasonInternetDisconnected)
+}
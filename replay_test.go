@@ -0,0 +1,160 @@
+package renderer
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+)
+
+func replayEntry(method, url, body string, status int, text string) HAREntry {
+	entry := HAREntry{
+		Request:  HARRequest{Method: method, URL: url},
+		Response: HARResponse{Status: status, Content: HARContent{Text: text}},
+	}
+	if body != "" {
+		entry.Request.PostData = &HARPostData{Text: body}
+	}
+	return entry
+}
+
+func TestReplayMatch(t *testing.T) {
+	har := &HAR{Log: HARLog{Entries: []HAREntry{
+		replayEntry("GET", "https://example.com/#top", "", 200, "page"),
+		replayEntry("GET", "https://example.com/poll", "", 200, "first"),
+		replayEntry("GET", "https://example.com/poll", "", 200, "second"),
+		replayEntry("GET", "https://example.com/failed", "", 0, ""),
+		replayEntry("POST", "https://example.com/api", `{"q":1}`, 200, "one"),
+		replayEntry("POST", "https://example.com/api", `{"q":2}`, 200, "two"),
+	}}}
+
+	p := newReplay(har, false)
+	tests := []struct {
+		method string
+		url    string
+		text   string
+		ok     bool
+	}{
+		{"GET", "https://example.com/", "page", true},
+		{"get", "https://example.com/#other", "page", true},
+		{"GET", "https://example.com/poll", "first", true},
+		{"GET", "https://example.com/poll", "second", true},
+		{"GET", "https://example.com/poll", "second", true},
+		{"GET", "https://example.com/failed", "", false},
+		{"GET", "https://example.com/missing", "", false},
+		{"POST", "https://example.com/", "", false},
+	}
+	for _, tt := range tests {
+		entry, ok := p.match(tt.method, tt.url, nil)
+		if ok != tt.ok || (ok && entry.Response.Content.Text != tt.text) {
+			t.Errorf("match(%s, %q) = (%+v, %v), want (%q, %v)", tt.method, tt.url, entry, ok, tt.text, tt.ok)
+		}
+	}
+
+	p = newReplay(har, true)
+	if entry, ok := p.match("POST", "https://example.com/api", []byte(`{"q":2}`)); !ok || entry.Response.Content.Text != "two" {
+		t.Errorf("match body q=2 = (%+v, %v), want two", entry, ok)
+	}
+	if entry, ok := p.match("POST", "https://example.com/api", []byte(`{"q":1}`)); !ok || entry.Response.Content.Text != "one" {
+		t.Errorf("match body q=1 = (%+v, %v), want one", entry, ok)
+	}
+	if _, ok := p.match("POST", "https://example.com/api", []byte(`{"q":3}`)); ok {
+		t.Error("match body q=3 found, want not recorded")
+	}
+}
+
+func TestReplayResponse(t *testing.T) {
+	entry := &HAREntry{Response: HARResponse{
+		Status:     201,
+		StatusText: "Created",
+		Headers: []HARNameValue{
+			{Name: "Content-Type", Value: "image/png"},
+			{Name: "content-encoding", Value: "gzip"},
+			{Name: "Content-Length", Value: "12"},
+		},
+		Content: HARContent{Text: base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G'}), Encoding: "base64"},
+	}}
+
+	params := replayResponse("paused", entry)
+	if params.ResponseCode != 201 || params.ResponsePhrase != "Created" {
+		t.Errorf("status = %d %q, want 201 Created", params.ResponseCode, params.ResponsePhrase)
+	}
+	want := []*fetch.HeaderEntry{{Name: "Content-Type", Value: "image/png"}}
+	if !reflect.DeepEqual(params.ResponseHeaders, want) {
+		t.Errorf("headers = %v, want %v", params.ResponseHeaders, want)
+	}
+	if body, _ := base64.StdEncoding.DecodeString(params.Body); string(body) != "\x89PNG" {
+		t.Errorf("body = %q, want decoded png bytes", body)
+	}
+}
+
+func TestInterceptorReplay(t *testing.T) {
+	har := &HAR{Log: HARLog{Entries: []HAREntry{
+		replayEntry("GET", "https://example.com/", "", 200, "<html></html>"),
+	}}}
+	paused := func(url string) *fetch.EventRequestPaused {
+		return &fetch.EventRequestPaused{
+			RequestID: "paused",
+			Request:   &network.Request{Method: "GET", URL: url},
+		}
+	}
+
+	i := newInterceptor(RendererConf{
+		Replay: har,
+		Mocks:  []MockRule{{URL: Glob("*/mocked"), Body: []byte("mock")}},
+	}, "https://example.com/")
+	if !i.enabled() {
+		t.Fatal("interceptor not enabled with replay")
+	}
+	if _, ok := i.handlers[1](paused("https://example.com/"), nil).(*fetch.FulfillRequestParams); !ok {
+		t.Error("recorded request is not fulfilled")
+	}
+	if action := i.handlers[0](paused("https://example.com/mocked"), nil); action == nil {
+		t.Error("mock rule is not applied before replay")
+	}
+	fail, ok := i.handlers[1](paused("https://example.com/missing"), nil).(*fetch.FailRequestParams)
+	if !ok || fail.ErrorReason != network.ErrorReasonInternetDisconnected {
+		t.Errorf("unmatched request = %#v, want failed", fail)
+	}
+	if misses := i.replayMisses(); !reflect.DeepEqual(misses, []string{"https://example.com/missing"}) {
+		t.Errorf("replayMisses() = %v", misses)
+	}
+
+	i = newInterceptor(RendererConf{Replay: har, ReplayNotFound: true}, "https://example.com/")
+	notFound, ok := i.handlers[0](paused("https://example.com/missing"), nil).(*fetch.FulfillRequestParams)
+	if !ok || notFound.ResponseCode != 404 {
+		t.Errorf("unmatched request = %#v, want 404", notFound)
+	}
+}
+
+func TestLoadHAR(t *testing.T) {
+	har := &HAR{Log: HARLog{
+		Version: "1.2",
+		Entries: []HAREntry{replayEntry("GET", "https://example.com/", "", 200, "page")},
+	}}
+	name := filepath.Join(t.TempDir(), "render.har")
+	if err := har.WriteFile(name); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadHAR(name)
+	if err != nil {
+		t.Fatalf("LoadHAR() = %v", err)
+	}
+	if len(loaded.Log.Entries) != 1 || loaded.Log.Entries[0].Response.Content.Text != "page" {
+		t.Errorf("entries = %+v", loaded.Log.Entries)
+	}
+
+	if _, err := LoadHAR(filepath.Join(t.TempDir(), "missing.har")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadHAR(missing) = %v, want not exist", err)
+	}
+	if _, err := ParseHAR(strings.NewReader("{")); err == nil {
+		t.Error("ParseHAR(invalid) = nil, want error")
+	}
+}
@@ -0,0 +1,67 @@
+package renderer
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+)
+
+// setupRequests returns action to set extra headers and cookies of the renderer
+// config before navigating to target url. Headers scoped to the origin of target are
+// added by interceptor instead.
+func setupRequests(conf RendererConf, target string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if len(conf.Headers) > 0 && !conf.HeadersOriginOnly {
+			headers := make(network.Headers, len(conf.Headers))
+			for name, value := range conf.Headers {
+				headers[name] = value
+			}
+			if err := network.SetExtraHTTPHeaders(headers).Do(ctx); err != nil {
+				return err
+			}
+		}
+
+		if len(conf.Cookies) > 0 {
+			cookies := make([]*network.CookieParam, 0, len(conf.Cookies))
+			for _, cookie := range conf.Cookies {
+				cookies = append(cookies, cookieParam(cookie, target))
+			}
+			if err := network.SetCookies(cookies).Do(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// cookieParam converts http cookie to cookie param, cookie without Domain is set for
+// target url
+func cookieParam(cookie *http.Cookie, target string) *network.CookieParam {
+	param := &network.CookieParam{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Domain:   cookie.Domain,
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HTTPOnly: cookie.HttpOnly,
+	}
+	if cookie.Domain == "" {
+		param.URL = target
+	}
+	if !cookie.Expires.IsZero() {
+		expires := cdp.TimeSinceEpoch(cookie.Expires)
+		param.Expires = &expires
+	}
+	switch cookie.SameSite {
+	case http.SameSiteLaxMode:
+		param.SameSite = network.CookieSameSiteLax
+	case http.SameSiteStrictMode:
+		param.SameSite = network.CookieSameSiteStrict
+	case http.SameSiteNoneMode:
+		param.SameSite = network.CookieSameSiteNone
+	}
+	return param
+}
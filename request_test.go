@@ -0,0 +1,36 @@
+package renderer
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestCookieParam(t *testing.T) {
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	param := cookieParam(&http.Cookie{
+		Name:     "session",
+		Value:    "abc",
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}, "https://staging.example.com/app")
+
+	if param.URL != "https://staging.example.com/app" || param.Domain != "" {
+		t.Errorf("URL, Domain = %q, %q, want cookie set for target url", param.URL, param.Domain)
+	}
+	if param.Expires == nil || !param.Expires.Time().Equal(expires) {
+		t.Errorf("Expires = %v, want %v", param.Expires, expires)
+	}
+	if !param.HTTPOnly || param.SameSite != network.CookieSameSiteLax {
+		t.Errorf("HTTPOnly, SameSite = %v, %q", param.HTTPOnly, param.SameSite)
+	}
+
+	param = cookieParam(&http.Cookie{Name: "id", Value: "1", Domain: ".example.com"}, "https://example.com")
+	if param.URL != "" || param.Domain != ".example.com" || param.Expires != nil {
+		t.Errorf("cookie with domain = %+v", param)
+	}
+}
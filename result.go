@@ -0,0 +1,173 @@
+package renderer
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+// Wait conditions which end the wait of a render, reported in RenderResult
+const (
+	WaitEndedByNetworkIdle            = "networkIdle"
+	WaitEndedByInteractiveTime        = "InteractiveTime"
+	WaitEndedBySelector               = "selector"
+	WaitEndedByExpression             = "expression"
+	WaitEndedByDOMContentLoaded       = "DOMContentLoaded"
+	WaitEndedByLoad                   = "load"
+	WaitEndedByFirstContentfulPaint   = "firstContentfulPaint"
+	WaitEndedByFirstMeaningfulPaint   = "firstMeaningfulPaint"
+	WaitEndedByLargestContentfulPaint = "largestContentfulPaint"
+	WaitEndedByDOMQuiet               = "domQuiet"
+	WaitEndedByTimeout                = "timeout"
+)
+
+// RenderResult is the output of a render along with details of the rendered page.
+type RenderResult struct {
+	// Rendered output, html content of RenderPageResult, pdf of RenderPdfResult or
+	// image of RenderScreenshotResult
+	Content []byte
+	// Outputs produced by RenderArtifacts
+	Artifacts *Artifacts
+	// Requested url
+	URL string
+	// Url of the page when rendering is done, after redirects
+	FinalURL string
+	// Title of the main frame
+	Title string
+	// Http status code of the main document response
+	StatusCode int
+	StatusText string
+	// Response headers of the main document
+	Headers http.Header
+	// Redirect responses of the main document in order
+	Redirects []Redirect
+	// Durations of each render phase
+	Timings RenderTimings
+	// Wait condition which ended the wait for the page to be ready
+	WaitEndedBy string
+	// Requests and idle timer events of network idle check
+	Idle *IdleReport
+	// Requests blocked by BlockResourceTypes, BlockURLs or FilterLists
+	Blocked []BlockedRequest
+	// Network activity of the render if RecordHAR is set
+	HAR *HAR
+	// Urls of requests not found in Replay
+	ReplayMisses []string
+	// Messages logged by the page with console api
+	Console []ConsoleMessage
+	// Uncaught javascript exceptions thrown by the page
+	Exceptions []JSException
+	// Attempts of the render in order, more than one if retried by WithRetry
+	Attempts []RenderAttempt
+}
+
+// RenderAttempt is an attempt of a render
+type RenderAttempt struct {
+	Start    time.Time
+	Duration time.Duration
+	// Error of the attempt, nil if the attempt succeeded
+	Err error
+}
+
+// BlockedRequest is a request blocked from loading while rendering
+type BlockedRequest struct {
+	URL          string
+	ResourceType string
+	// Rule which blocked the request, filter rule text of FilterLists, url pattern of
+	// BlockURLs or resourceType:<type> of BlockResourceTypes
+	Rule string
+}
+
+// Redirect is a redirect response received when loading the main document
+type Redirect struct {
+	URL        string
+	StatusCode int
+	Location   string
+}
+
+// RenderTimings records durations of each render phase
+type RenderTimings struct {
+	// Launch browser or open a new tab
+	Tab time.Duration
+	// Navigate to the url until main document is committed
+	Navigation time.Duration
+	// Wait for the page to be ready
+	Wait time.Duration
+	// Produce output from the loaded page
+	Capture time.Duration
+	Total   time.Duration
+}
+
+// documentRecord keeps main document responses and redirects received by a render
+type documentRecord struct {
+	mu        sync.Mutex
+	redirects map[cdp.FrameID][]Redirect
+	responses map[cdp.FrameID]*network.Response // latest document response of frame
+	requests  map[cdp.FrameID]network.RequestID // request of the latest document response
+}
+
+func newDocumentRecord() *documentRecord {
+	return &documentRecord{
+		redirects: make(map[cdp.FrameID][]Redirect),
+		responses: make(map[cdp.FrameID]*network.Response),
+		requests:  make(map[cdp.FrameID]network.RequestID),
+	}
+}
+
+func (d *documentRecord) addRedirect(frameID cdp.FrameID, resp *network.Response) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.redirects[frameID] = append(d.redirects[frameID], Redirect{
+		URL:        resp.URL,
+		StatusCode: int(resp.Status),
+		Location:   toHTTPHeader(resp.Headers).Get("Location"),
+	})
+}
+
+func (d *documentRecord) addResponse(
+	frameID cdp.FrameID,
+	requestID network.RequestID,
+	resp *network.Response,
+) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.responses[frameID] = resp
+	d.requests[frameID] = requestID
+}
+
+// response returns the latest document response of the frame and its request, nil if
+// no response is received
+func (d *documentRecord) response(frameID cdp.FrameID) (network.RequestID, *network.Response) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.requests[frameID], d.responses[frameID]
+}
+
+// fill sets main document details of the frame to the render result
+func (d *documentRecord) fill(frameID cdp.FrameID, res *RenderResult) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res.Redirects = d.redirects[frameID]
+	if resp, ok := d.responses[frameID]; ok {
+		res.StatusCode = int(resp.Status)
+		res.StatusText = resp.StatusText
+		res.Headers = toHTTPHeader(resp.Headers)
+	}
+}
+
+// toHTTPHeader converts devtools headers to http.Header, multiple values of a header
+// are separated by newline in devtools headers.
+func toHTTPHeader(headers network.Headers) http.Header {
+	h := make(http.Header, len(headers))
+	for key, value := range headers {
+		for _, v := range strings.Split(fmt.Sprint(value), "\n") {
+			h.Add(key, v)
+		}
+	}
+	return h
+}
@@ -0,0 +1,58 @@
+package renderer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/network"
+)
+
+func TestToHTTPHeader(t *testing.T) {
+	h := toHTTPHeader(network.Headers{
+		"content-type": "text/html",
+		"Set-Cookie":   "a=1\nb=2",
+	})
+
+	if got := h.Get("Content-Type"); got != "text/html" {
+		t.Errorf("Content-Type = %q, want %q", got, "text/html")
+	}
+	if got := h.Values("Set-Cookie"); !reflect.DeepEqual(got, []string{"a=1", "b=2"}) {
+		t.Errorf("Set-Cookie = %q, want %q", got, []string{"a=1", "b=2"})
+	}
+}
+
+func TestDocumentRecordFill(t *testing.T) {
+	d := newDocumentRecord()
+	main := cdp.FrameID("main")
+
+	d.addRedirect(main, &network.Response{
+		URL:     "http://example.com/old",
+		Status:  301,
+		Headers: network.Headers{"Location": "/new"},
+	})
+	d.addResponse("iframe", "ad", &network.Response{URL: "http://example.com/ad", Status: 200})
+	d.addResponse(main, "doc", &network.Response{
+		URL:        "http://example.com/new",
+		Status:     404,
+		StatusText: "Not Found",
+		Headers:    network.Headers{"Content-Type": "text/html"},
+	})
+
+	var res RenderResult
+	d.fill(main, &res)
+
+	want := []Redirect{{URL: "http://example.com/old", StatusCode: 301, Location: "/new"}}
+	if !reflect.DeepEqual(res.Redirects, want) {
+		t.Errorf("Redirects = %+v, want %+v", res.Redirects, want)
+	}
+	if res.StatusCode != 404 || res.StatusText != "Not Found" {
+		t.Errorf("status = %d %s, want 404 Not Found", res.StatusCode, res.StatusText)
+	}
+	if got := res.Headers.Get("Content-Type"); got != "text/html" {
+		t.Errorf("Content-Type = %q, want %q", got, "text/html")
+	}
+	if id, resp := d.response(main); id != "doc" || resp == nil || resp.Status != 404 {
+		t.Errorf("response(main) = (%s, %+v), want request doc with status 404", id, resp)
+	}
+}
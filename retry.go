@@ -0,0 +1,116 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// transientNetErrors are net error codes of navigation worth retrying
+var transientNetErrors = []string{
+	"net::ERR_TIMED_OUT",
+	"net::ERR_CONNECTION_TIMED_OUT",
+	"net::ERR_CONNECTION_RESET",
+	"net::ERR_CONNECTION_CLOSED",
+	"net::ERR_CONNECTION_ABORTED",
+	"net::ERR_EMPTY_RESPONSE",
+	"net::ERR_NETWORK_CHANGED",
+}
+
+// retryPolicy decides how renders failed with transient errors are retried
+type retryPolicy struct {
+	attempts  int           // maximum attempts in total, no retry if less than 2
+	baseDelay time.Duration // delay before the first retry
+	maxDelay  time.Duration // maximum delay before a retry, not capped if 0
+}
+
+// backoff returns delay before retrying the failed attempt, which doubles every
+// attempt up to maxDelay with random jitter of up to half of the delay
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.baseDelay
+	for i := 1; i < attempt && (p.maxDelay <= 0 || delay < p.maxDelay); i++ {
+		delay *= 2
+	}
+	if p.maxDelay > 0 && delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return delay - half + rand.N(half+1)
+}
+
+// retryRender runs renderOnce until it succeeds, fails with error which is not
+// transient, ctx is done or attempts of the retry policy are used up. Failed attempts
+// are logged and every attempt is recorded in the returned result.
+func (r *Renderer) retryRender(
+	ctx context.Context,
+	urlStr string,
+	renderOnce func() (*RenderResult, error),
+) (*RenderResult, error) {
+	var attempts []RenderAttempt
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		res, err := renderOnce()
+		attempts = append(attempts, RenderAttempt{Start: start, Duration: time.Since(start), Err: err})
+		if res != nil {
+			res.Attempts = attempts
+		}
+		if err == nil || attempt >= r.retry.attempts || ctx.Err() != nil || !IsTransient(err) {
+			return res, err
+		}
+
+		delay := r.retry.backoff(attempt)
+		r.logger.Warn(
+			fmt.Sprintf("render attempt %d/%d failed, retry in %v: %s", attempt, r.retry.attempts, delay, err),
+			slog.String("url", urlStr),
+		)
+		if ctxErr := sleepContext(ctx, delay); ctxErr != nil {
+			return res, fmt.Errorf("%w: %w", ctxErr, err)
+		}
+	}
+}
+
+// IsTransient reports whether err of a render is a transient failure which may
+// succeed when retried: browser crash, connection reset, net error such as
+// net::ERR_TIMED_OUT, or wait timeout. Browser failed to launch or connect is not
+// transient, eg. wrong BrowserExecPath would fail every attempt.
+func IsTransient(err error) bool {
+	var navErr *NavigationError
+	switch {
+	case errors.Is(err, ErrBrowserLaunch):
+		// launch error may wrap connection error, eg. remote browser reset the dial
+		return false
+	case errors.Is(err, ErrBrowserCrashed),
+		errors.Is(err, ErrWaitTimeout):
+		return true
+	case errors.As(err, &navErr):
+		return slices.Contains(transientNetErrors, navErr.Code)
+	case errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, chromedp.ErrChannelClosed):
+		return true
+	}
+	return false
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,172 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("%w: exec: \"chrome\": executable file not found", ErrBrowserLaunch), false},
+		{fmt.Errorf("%w: dial tcp: connection refused", ErrBrowserLaunch), false},
+		{fmt.Errorf("%w: read: %w", ErrBrowserLaunch, syscall.ECONNRESET), false},
+		{fmt.Errorf("%w: %w", ErrBrowserLaunch, chromedp.ErrChannelClosed), false},
+		{fmt.Errorf("%w: context canceled", ErrBrowserCrashed), true},
+		{&WaitTimeoutError{Err: context.DeadlineExceeded}, true},
+		{&NavigationError{Code: "net::ERR_TIMED_OUT"}, true},
+		{&NavigationError{Code: "net::ERR_CONNECTION_RESET"}, true},
+		{&NavigationError{Code: "net::ERR_NAME_NOT_RESOLVED"}, false},
+		{fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{io.ErrUnexpectedEOF, true},
+		{chromedp.ErrChannelClosed, true},
+		{fmt.Errorf("%w: idle type", ErrInvalidOption), false},
+		{&ResponseError{StatusCode: 500, Reason: ResponseErrorStatus}, false},
+		{&JSError{}, false},
+		{ErrBrowserClosed, false},
+		{context.Canceled, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsTransient(tt.err); got != tt.want {
+			t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := retryPolicy{attempts: 5, baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+	tests := []struct {
+		attempt int
+		delay   time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{60, time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			got := p.backoff(tt.attempt)
+			if got < tt.delay/2 || got > tt.delay {
+				t.Errorf("backoff(%d) = %v, want in [%v, %v]", tt.attempt, got, tt.delay/2, tt.delay)
+			}
+		}
+	}
+
+	if got := (retryPolicy{attempts: 3}).backoff(2); got != 0 {
+		t.Errorf("backoff without delay = %v, want 0", got)
+	}
+	if got := (retryPolicy{baseDelay: time.Second}).backoff(4); got < 4*time.Second || got > 8*time.Second {
+		t.Errorf("backoff without max delay = %v, want in [4s, 8s]", got)
+	}
+}
+
+func TestRetryRender(t *testing.T) {
+	var logs bytes.Buffer
+	r := NewRenderer(
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+		WithRetry(3, time.Millisecond, 5*time.Millisecond),
+	)
+
+	tests := []struct {
+		name     string
+		errs     []error
+		attempts int
+	}{
+		{"success", []error{nil}, 1},
+		{"crash then success", []error{fmt.Errorf("%w: context canceled", ErrBrowserCrashed), nil}, 2},
+		{"wait timeout", []error{
+			&WaitTimeoutError{Err: context.DeadlineExceeded},
+			&WaitTimeoutError{Err: context.DeadlineExceeded},
+			&WaitTimeoutError{Err: context.DeadlineExceeded},
+		}, 3},
+		{"browser launch", []error{fmt.Errorf("%w: exec: not found", ErrBrowserLaunch)}, 1},
+		{"invalid option", []error{fmt.Errorf("%w: idle type", ErrInvalidOption)}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			calls := 0
+			res, err := r.retryRender(context.Background(), "http://example.com", func() (*RenderResult, error) {
+				err := tt.errs[calls]
+				calls++
+				return &RenderResult{}, err
+			})
+			if calls != tt.attempts {
+				t.Fatalf("attempts = %d, want %d", calls, tt.attempts)
+			}
+			if want := tt.errs[len(tt.errs)-1]; err != want {
+				t.Errorf("err = %v, want %v", err, want)
+			}
+			if len(res.Attempts) != tt.attempts {
+				t.Errorf("recorded attempts = %d, want %d", len(res.Attempts), tt.attempts)
+			}
+			if retries := strings.Count(logs.String(), "render attempt"); retries != tt.attempts-1 {
+				t.Errorf("retries logged = %d, want %d:\n%s", retries, tt.attempts-1, logs.String())
+			}
+		})
+	}
+}
+
+func TestRetryRenderContextDone(t *testing.T) {
+	r := NewRenderer(
+		WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+		WithRetry(5, time.Hour, 0),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := r.retryRender(ctx, "http://example.com", func() (*RenderResult, error) {
+		return nil, fmt.Errorf("%w: context canceled", ErrBrowserCrashed)
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrBrowserCrashed) {
+		t.Errorf("render err = %v, want deadline exceeded while waiting to retry", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("render took %v, want stop waiting when ctx is done", elapsed)
+	}
+}
+
+func TestRenderRetryBrowserLaunch(t *testing.T) {
+	// remote browser which is not listening fails to connect, not worth retrying
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remoteURL := "ws://" + ln.Addr().String()
+	ln.Close()
+
+	var logs bytes.Buffer
+	r := NewRenderer(
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+		WithRetry(3, time.Millisecond, 5*time.Millisecond),
+	)
+	opts := DefaultRendererOption
+	opts.BrowserOpts.RemoteURL = remoteURL
+	opts.Opts.Timeout = 5
+
+	_, err = r.RenderPageResult(context.Background(), "http://example.com", &opts)
+	if !errors.Is(err, ErrBrowserLaunch) {
+		t.Fatalf("render err = %v, want ErrBrowserLaunch", err)
+	}
+	if strings.Contains(logs.String(), "render attempt") {
+		t.Errorf("browser launch failure retried:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,174 @@
+package renderer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+const defaultScreenshotFormat = "png"
+
+// ClipRect is the area of the page to capture in css pixels
+type ClipRect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+type ScreenshotOption struct {
+	BrowserOpts  BrowserConf
+	RendererOpts RendererConf
+	// Image format, valid values: png, jpeg, webp
+	Format string
+	// Compression quality from 0 to 100, only for jpeg and webp
+	Quality int
+	// Capture the whole page beyond the window size
+	FullPage bool
+	// Capture bounding box of the first element matching the css selector
+	Selector string
+	// Capture the given area of the page
+	Clip *ClipRect
+	// Device scale factor, 0 to use the default of browser
+	Scale float64
+}
+
+var DefaultScreenshotOption = ScreenshotOption{
+	BrowserOpts: BrowserConf{
+		IdleType: defaultIdleType,
+	},
+	RendererOpts: RendererConf{
+		Headless:     true,
+		WindowWidth:  defaultWindowWidth,
+		WindowHeight: defaultWindowHeight,
+		Timeout:      defaultTimeout,
+		ImageLoad:    true,
+	},
+	Format: defaultScreenshotFormat,
+}
+
+func (opts ScreenshotOption) readBrowserConf() BrowserConf {
+	return opts.BrowserOpts
+}
+
+func (opts ScreenshotOption) readRendererConf() RendererConf {
+	return opts.RendererOpts
+}
+
+// validate checks if screenshot settings are valid
+func (opts *ScreenshotOption) validate() error {
+	if opts.Format != "" && !slices.Contains([]string{"png", "jpeg", "webp"}, opts.Format) {
+		return fmt.Errorf("%w: invalid screenshot format %s", ErrInvalidOption, opts.Format)
+	}
+	if opts.Quality < 0 || opts.Quality > 100 {
+		return fmt.Errorf("%w: invalid screenshot quality %d", ErrInvalidOption, opts.Quality)
+	}
+	if opts.Scale < 0 {
+		return fmt.Errorf("%w: invalid screenshot scale %v", ErrInvalidOption, opts.Scale)
+	}
+
+	areas := 0
+	for _, set := range []bool{opts.FullPage, opts.Selector != "", opts.Clip != nil} {
+		if set {
+			areas++
+		}
+	}
+	if areas > 1 {
+		return fmt.Errorf("%w: only one of FullPage, Selector and Clip can be set", ErrInvalidOption)
+	}
+
+	return nil
+}
+
+// capture takes screenshot of the loaded page according to the option settings
+func (opts *ScreenshotOption) capture(ctx context.Context) ([]byte, error) {
+	if opts.Scale != 0 {
+		err := emulation.SetDeviceMetricsOverride(
+			int64(opts.RendererOpts.WindowWidth),
+			int64(opts.RendererOpts.WindowHeight),
+			opts.Scale,
+			false,
+		).Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = defaultScreenshotFormat
+	}
+	params := page.CaptureScreenshot().WithFormat(page.CaptureScreenshotFormat(format))
+	if format != "png" && opts.Quality != 0 {
+		params = params.WithQuality(int64(opts.Quality))
+	}
+
+	clip := opts.Clip
+	switch {
+	case opts.FullPage:
+		_, _, _, _, _, contentSize, err := page.GetLayoutMetrics().Do(ctx)
+		if err != nil {
+			return nil, err
+		}
+		clip = &ClipRect{Width: contentSize.Width, Height: contentSize.Height}
+	case opts.Selector != "":
+		rect, err := selectorRect(ctx, opts.Selector)
+		if err != nil {
+			return nil, err
+		}
+		clip = rect
+	}
+	if clip != nil {
+		params = params.
+			WithCaptureBeyondViewport(true).
+			WithClip(&page.Viewport{
+				X:      clip.X,
+				Y:      clip.Y,
+				Width:  clip.Width,
+				Height: clip.Height,
+				Scale:  1,
+			})
+	}
+
+	return params.Do(ctx)
+}
+
+// selectorRect returns bounding box of the first element matching selector relative
+// to the top left of the page
+func selectorRect(ctx context.Context, selector string) (*ClipRect, error) {
+	quoted, err := json.Marshal(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var rect *ClipRect
+	err = chromedp.Evaluate(fmt.Sprintf(`(() => {
+		const el = document.querySelector(%s);
+		if (!el) {
+			return null;
+		}
+		const rect = el.getBoundingClientRect();
+		return {
+			x: rect.left + window.scrollX,
+			y: rect.top + window.scrollY,
+			width: rect.width,
+			height: rect.height,
+		};
+	})()`, quoted), &rect).Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if rect == nil {
+		return nil, fmt.Errorf("selector %s not found", selector)
+	}
+	if rect.Width == 0 || rect.Height == 0 {
+		return nil, fmt.Errorf("selector %s has empty bounding box", selector)
+	}
+
+	return rect, nil
+}
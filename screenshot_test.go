@@ -0,0 +1,40 @@
+package renderer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestScreenshotOptionValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  ScreenshotOption
+		valid bool
+	}{
+		{"default", DefaultScreenshotOption, true},
+		{"jpeg quality", ScreenshotOption{Format: "jpeg", Quality: 80}, true},
+		{"selector", ScreenshotOption{Selector: "#app", Scale: 2}, true},
+		{"clip", ScreenshotOption{Clip: &ClipRect{Width: 100, Height: 100}}, true},
+		{"unknown format", ScreenshotOption{Format: "gif"}, false},
+		{"quality out of range", ScreenshotOption{Format: "jpeg", Quality: 101}, false},
+		{"negative scale", ScreenshotOption{Scale: -1}, false},
+		{"full page and selector", ScreenshotOption{FullPage: true, Selector: "#app"}, false},
+		{
+			"selector and clip",
+			ScreenshotOption{Selector: "#app", Clip: &ClipRect{Width: 1, Height: 1}},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if tt.valid && err != nil {
+				t.Errorf("validate() = %v, want nil", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidOption) {
+				t.Errorf("validate() = %v, want %v", err, ErrInvalidOption)
+			}
+		})
+	}
+}
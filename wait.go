@@ -0,0 +1,597 @@
+package renderer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// Selector states to wait for with idle type selector
+const (
+	SelectorPresent = "present"
+	SelectorVisible = "visible"
+	SelectorHidden  = "hidden"
+)
+
+const waitPollInterval = 100 * time.Millisecond
+
+// defaultLCPStableWindow is used when LCPStableWindow of BrowserConf is not set
+const defaultLCPStableWindow = 500 * time.Millisecond
+
+// defaultDOMQuietWindow is used when DOMQuietWindow of BrowserConf is not set
+const defaultDOMQuietWindow = 500 * time.Millisecond
+
+// errNoRenderState is returned when built-in wait condition is used outside of a render
+var errNoRenderState = errors.New("wait condition used outside of render")
+
+// WaitCondition is a condition for the rendered page to be ready. Wait blocks until
+// the condition is met and returns nil, or returns error when ctx is done or the
+// condition can not be met. ctx is the chromedp context of the rendered page, so
+// chromedp actions can be run with it. String names the condition, which is
+// reported as WaitEndedBy of RenderResult.
+type WaitCondition interface {
+	Wait(ctx context.Context) error
+	String() string
+}
+
+type waitContextKey struct{}
+
+// waitContext is carried by ctx given to WaitCondition
+type waitContext struct {
+	renderer *Renderer
+	state    *renderState
+	endedBy  *string // name of the condition which ended the wait
+}
+
+func withWaitContext(ctx context.Context, wc waitContext) context.Context {
+	return context.WithValue(ctx, waitContextKey{}, wc)
+}
+
+func waitContextFrom(ctx context.Context) (waitContext, error) {
+	wc, ok := ctx.Value(waitContextKey{}).(waitContext)
+	if !ok {
+		return waitContext{}, errNoRenderState
+	}
+	return wc, nil
+}
+
+// idleTypeCondition returns wait condition of the idle type settings in conf
+func idleTypeCondition(conf BrowserConf) WaitCondition {
+	if conf.WaitCondition != nil {
+		return conf.WaitCondition
+	}
+
+	switch conf.IdleType {
+	case "networkIdle":
+		return NetworkIdle()
+	case "InteractiveTime":
+		return InteractiveTime()
+	case "selector":
+		return Selector(conf.WaitSelector, conf.WaitSelectorState)
+	case "expression":
+		return Expression(conf.WaitExpression)
+	case "DOMContentLoaded", "load", "firstContentfulPaint", "firstMeaningfulPaint":
+		return Lifecycle(conf.IdleType)
+	case "largestContentfulPaint":
+		return LargestContentfulPaint(conf.LCPStableWindow)
+	case "domQuiet":
+		return DOMQuiet(conf.DOMQuietWindow, conf.DOMQuietFrames)
+	default:
+		return Any(NetworkIdle(), InteractiveTime())
+	}
+}
+
+type networkIdleCondition struct{}
+
+// NetworkIdle is met when network is idle according to the WithIdleCheck settings
+func NetworkIdle() WaitCondition {
+	return networkIdleCondition{}
+}
+
+func (networkIdleCondition) String() string {
+	return WaitEndedByNetworkIdle
+}
+
+func (networkIdleCondition) Wait(ctx context.Context) error {
+	wc, err := waitContextFrom(ctx)
+	if err != nil {
+		return err
+	}
+	idleCheck := wc.state.idleCheck
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	// Initial arm (in case we’re already quiet)
+	idleCheck.mu.Lock()
+	idleCheck.startOrResetTimer()
+	idleCheck.mu.Unlock()
+
+	for {
+		select {
+		case <-ticker.C:
+			idleCheck.promoteLongPoll()
+		case <-idleCheck.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+type interactiveTimeCondition struct{}
+
+// InteractiveTime is met when browser reports InteractiveTime lifecycle event
+func InteractiveTime() WaitCondition {
+	return interactiveTimeCondition{}
+}
+
+func (interactiveTimeCondition) String() string {
+	return WaitEndedByInteractiveTime
+}
+
+func (interactiveTimeCondition) Wait(ctx context.Context) error {
+	wc, err := waitContextFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-wc.state.interactiveCheck.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type lifecycleCondition string
+
+// Lifecycle is met when browser reports lifecycle event of the given name for the
+// main frame of current document, eg. DOMContentLoaded, load, firstContentfulPaint or
+// firstMeaningfulPaint.
+func Lifecycle(name string) WaitCondition {
+	return lifecycleCondition(name)
+}
+
+func (c lifecycleCondition) String() string {
+	return string(c)
+}
+
+func (c lifecycleCondition) Wait(ctx context.Context) error {
+	wc, err := waitContextFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-wc.state.lifecycle.fired(string(c)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type lcpCondition time.Duration
+
+// LargestContentfulPaint is met when largest contentful paint of the page has not
+// changed for window since it was last reported. defaultLCPStableWindow is used if
+// window is not positive. Page without contentful element never reports largest
+// contentful paint.
+func LargestContentfulPaint(window time.Duration) WaitCondition {
+	if window <= 0 {
+		window = defaultLCPStableWindow
+	}
+	return lcpCondition(window)
+}
+
+func (c lcpCondition) String() string {
+	return WaitEndedByLargestContentfulPaint
+}
+
+func (c lcpCondition) Wait(ctx context.Context) error {
+	return pollExpression(ctx, lcpExpression(time.Duration(c)))
+}
+
+type domQuietCondition struct {
+	window time.Duration
+	frames int
+}
+
+// DOMQuiet is met when no DOM mutation occurred in the page for window, and no more
+// mutation occurred during the following frames animation frames.
+// defaultDOMQuietWindow is used if window is not positive. Combine with NetworkIdle
+// using All for pages rendering after requests finish.
+func DOMQuiet(window time.Duration, frames int) WaitCondition {
+	if window <= 0 {
+		window = defaultDOMQuietWindow
+	}
+	return domQuietCondition{window: window, frames: max(frames, 0)}
+}
+
+func (c domQuietCondition) String() string {
+	return WaitEndedByDOMQuiet
+}
+
+func (c domQuietCondition) Wait(ctx context.Context) error {
+	return pollExpression(ctx, domQuietExpression(c.window, c.frames))
+}
+
+type selectorCondition struct {
+	selector string
+	state    string
+}
+
+// Selector is met when the first element matching css selector is in state, valid
+// states are SelectorPresent (default if empty), SelectorVisible and SelectorHidden.
+func Selector(selector, state string) WaitCondition {
+	return selectorCondition{selector: selector, state: state}
+}
+
+func (c selectorCondition) String() string {
+	return WaitEndedBySelector
+}
+
+func (c selectorCondition) Wait(ctx context.Context) error {
+	expression, err := selectorExpression(c.selector, c.state)
+	if err != nil {
+		return err
+	}
+	return pollExpression(ctx, expression)
+}
+
+type expressionCondition string
+
+// Expression is met when js expression evaluated in the page is truthy, promise
+// result is awaited.
+func Expression(expression string) WaitCondition {
+	return expressionCondition(expression)
+}
+
+func (c expressionCondition) String() string {
+	return WaitEndedByExpression
+}
+
+func (c expressionCondition) Wait(ctx context.Context) error {
+	return pollExpression(ctx, string(c))
+}
+
+// FontsReady is met when every font of the document is loaded
+func FontsReady() WaitCondition {
+	return namedCondition{
+		name:      "fontsReady",
+		condition: Expression(`document.fonts.ready.then(() => true)`),
+	}
+}
+
+type delayCondition time.Duration
+
+// Delay is met after duration d since waiting started
+func Delay(d time.Duration) WaitCondition {
+	return delayCondition(d)
+}
+
+func (c delayCondition) String() string {
+	return fmt.Sprintf("delay(%v)", time.Duration(c))
+}
+
+func (c delayCondition) Wait(ctx context.Context) error {
+	timer := time.NewTimer(time.Duration(c))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type sinceLoadCondition time.Duration
+
+// SinceLoad is met when at least duration d passed since load event of the page
+func SinceLoad(d time.Duration) WaitCondition {
+	return sinceLoadCondition(d)
+}
+
+func (c sinceLoadCondition) String() string {
+	return fmt.Sprintf("sinceLoad(%v)", time.Duration(c))
+}
+
+func (c sinceLoadCondition) Wait(ctx context.Context) error {
+	wc, err := waitContextFrom(ctx)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-wc.state.lifecycle.fired("load"):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	remain := time.Duration(c) - time.Since(wc.state.lifecycle.firedAt("load"))
+	return delayCondition(remain).Wait(ctx)
+}
+
+type namedCondition struct {
+	name      string
+	condition WaitCondition
+}
+
+func (c namedCondition) String() string {
+	return c.name
+}
+
+func (c namedCondition) Wait(ctx context.Context) error {
+	return c.condition.Wait(ctx)
+}
+
+type allCondition []WaitCondition
+
+// All is met when every condition is met, conditions are waited concurrently
+func All(conditions ...WaitCondition) WaitCondition {
+	return allCondition(conditions)
+}
+
+func (c allCondition) String() string {
+	return "all(" + joinConditions(c) + ")"
+}
+
+func (c allCondition) Wait(ctx context.Context) error {
+	// conditions used outside of render are waited without wait context
+	wc, wcErr := waitContextFrom(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(c))
+	for _, condition := range c {
+		go func() {
+			cctx := ctx
+			if wcErr == nil {
+				// every condition reports to its own endedBy, so nested Any do not
+				// write the same one concurrently
+				endedBy := condition.String()
+				cwc := wc
+				cwc.endedBy = &endedBy
+				cctx = withWaitContext(ctx, cwc)
+			}
+			errs <- condition.Wait(cctx)
+		}()
+	}
+	for range c {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	if wcErr == nil {
+		*wc.endedBy = c.String()
+	}
+	return nil
+}
+
+type anyCondition []WaitCondition
+
+// Any is met as soon as one of the conditions is met, conditions are waited
+// concurrently. The met condition is reported as WaitEndedBy of RenderResult.
+func Any(conditions ...WaitCondition) WaitCondition {
+	return anyCondition(conditions)
+}
+
+func (c anyCondition) String() string {
+	return "any(" + joinConditions(c) + ")"
+}
+
+func (c anyCondition) Wait(ctx context.Context) error {
+	wc, err := waitContextFrom(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		endedBy string
+		err     error
+	}
+	results := make(chan result, len(c))
+	for _, condition := range c {
+		go func() {
+			endedBy := condition.String()
+			cwc := wc
+			cwc.endedBy = &endedBy
+			err := condition.Wait(withWaitContext(ctx, cwc))
+			results <- result{endedBy: endedBy, err: err}
+		}()
+	}
+
+	var errs []error
+	for range c {
+		res := <-results
+		if res.err == nil {
+			*wc.endedBy = res.endedBy
+			return nil
+		}
+		errs = append(errs, res.err)
+	}
+	return errors.Join(errs...)
+}
+
+func joinConditions(conditions []WaitCondition) string {
+	names := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		names = append(names, condition.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// lifecycle records lifecycle events of the main frame of current document
+type lifecycle struct {
+	mu      sync.Mutex
+	times   map[string]time.Time
+	waiters map[string]chan struct{} // closed when the event is fired
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		times:   make(map[string]time.Time),
+		waiters: make(map[string]chan struct{}),
+	}
+}
+
+func (l *lifecycle) waiter(name string) chan struct{} {
+	ch, ok := l.waiters[name]
+	if !ok {
+		ch = make(chan struct{})
+		l.waiters[name] = ch
+	}
+	return ch
+}
+
+// fire records the event, only the first event of the same name is recorded for a
+// document
+func (l *lifecycle) fire(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.times[name]; ok {
+		return
+	}
+	l.times[name] = time.Now()
+	close(l.waiter(name))
+}
+
+// reset clears fired events when a new document is loaded, waiters of events not
+// fired yet keep waiting.
+func (l *lifecycle) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for name := range l.times {
+		delete(l.waiters, name)
+	}
+	clear(l.times)
+}
+
+// fired returns channel which is closed when the event is fired
+func (l *lifecycle) fired(name string) <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.waiter(name)
+}
+
+// firedAt returns the time event is fired, zero time if not fired yet
+func (l *lifecycle) firedAt(name string) time.Time {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.times[name]
+}
+
+// selectorExpression returns js expression which is truthy when the element matching
+// selector is in the given state
+func selectorExpression(selector, state string) (string, error) {
+	quoted, err := json.Marshal(selector)
+	if err != nil {
+		return "", err
+	}
+
+	visible := `!!el && (el.offsetWidth > 0 || el.offsetHeight > 0 || el.getClientRects().length > 0) &&
+		window.getComputedStyle(el).visibility !== "hidden"`
+	var check string
+	switch state {
+	case "", SelectorPresent:
+		check = "!!el"
+	case SelectorVisible:
+		check = visible
+	case SelectorHidden:
+		check = "!(" + visible + ")"
+	default:
+		return "", fmt.Errorf("%w: invalid selector state %s", ErrInvalidOption, state)
+	}
+
+	return fmt.Sprintf(`(() => {
+		const el = document.querySelector(%s);
+		return %s;
+	})()`, quoted, check), nil
+}
+
+// lcpExpression returns js expression which is truthy when largest contentful paint
+// is reported and not changed for window. Observer is installed on first evaluation,
+// buffered entries reported before that are delivered to it.
+func lcpExpression(window time.Duration) string {
+	return fmt.Sprintf(`(() => {
+		if (!window.__rendererLCP) {
+			const lcp = window.__rendererLCP = { seen: false, at: 0 };
+			new PerformanceObserver((list) => {
+				const entries = list.getEntries();
+				lcp.seen = true;
+				lcp.at = Math.max(lcp.at, entries[entries.length - 1].startTime);
+			}).observe({ type: "largest-contentful-paint", buffered: true });
+		}
+		const lcp = window.__rendererLCP;
+		return lcp.seen && performance.now() - lcp.at >= %d;
+	})()`, window.Milliseconds())
+}
+
+// domQuietExpression returns js expression which is truthy when no DOM mutation
+// occurred for window and during the following frames animation frames. Observer is
+// installed on first evaluation, which counts as the last mutation since earlier
+// mutations are unknown.
+func domQuietExpression(window time.Duration, frames int) string {
+	return fmt.Sprintf(`(() => {
+		if (!window.__rendererDOMQuiet) {
+			const quiet = window.__rendererDOMQuiet = { at: performance.now() };
+			new MutationObserver(() => {
+				quiet.at = performance.now();
+			}).observe(document, { subtree: true, childList: true, attributes: true, characterData: true });
+		}
+		const quiet = window.__rendererDOMQuiet;
+		if (performance.now() - quiet.at < %d) {
+			return false;
+		}
+		const at = quiet.at;
+		return new Promise((resolve) => {
+			let frames = %d;
+			const next = () => frames-- > 0 ? requestAnimationFrame(next) : resolve(quiet.at === at);
+			next();
+		});
+	})()`, window.Milliseconds(), frames)
+}
+
+// pollExpression evaluates js expression in the page periodically until the result
+// is truthy. Promise result is awaited. Evaluation error, eg. page context destroyed
+// by navigation, is considered as not ready yet.
+func pollExpression(ctx context.Context, expression string) error {
+	logger := slog.Default()
+	if wc, err := waitContextFrom(ctx); err == nil {
+		logger = wc.renderer.logger
+	}
+
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	wrapped := fmt.Sprintf(`Promise.resolve(%s).then((v) => !!v)`, expression)
+	for {
+		var ready bool
+		err := chromedp.Evaluate(wrapped, &ready, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+			return p.WithAwaitPromise(true)
+		}).Do(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Debug("poll expression error", slog.String("error", err.Error()))
+		}
+		if ready {
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
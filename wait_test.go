@@ -0,0 +1,210 @@
+package renderer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCondition is met after delay, or fails with err if set
+type testCondition struct {
+	name  string
+	delay time.Duration
+	err   error
+}
+
+func (c testCondition) String() string {
+	return c.name
+}
+
+func (c testCondition) Wait(ctx context.Context) error {
+	if err := Delay(c.delay).Wait(ctx); err != nil {
+		return err
+	}
+	return c.err
+}
+
+// waitTest waits for condition with a fresh render state and returns the condition
+// which ended the wait
+func waitTest(
+	t *testing.T,
+	condition WaitCondition,
+	timeout time.Duration,
+) (*renderState, string, error) {
+	t.Helper()
+
+	r := NewRenderer(WithIdleCheck(20*time.Millisecond, 0))
+	state := r.newRenderState()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	endedBy := condition.String()
+	err := condition.Wait(withWaitContext(ctx, waitContext{
+		renderer: r,
+		state:    state,
+		endedBy:  &endedBy,
+	}))
+	return state, endedBy, err
+}
+
+func TestAnyCondition(t *testing.T) {
+	_, endedBy, err := waitTest(t, Any(
+		testCondition{name: "slow", delay: time.Second},
+		Any(
+			testCondition{name: "failed", err: errors.New("failed")},
+			testCondition{name: "fast", delay: 10 * time.Millisecond},
+		),
+	), time.Second)
+	if err != nil {
+		t.Fatalf("Wait() = %v", err)
+	}
+	if endedBy != "fast" {
+		t.Errorf("endedBy = %q, want %q", endedBy, "fast")
+	}
+
+	failed := errors.New("failed")
+	_, _, err = waitTest(t, Any(
+		testCondition{name: "a", err: failed},
+		testCondition{name: "b", err: failed},
+	), time.Second)
+	if !errors.Is(err, failed) {
+		t.Errorf("Wait() with all failed = %v, want %v", err, failed)
+	}
+}
+
+func TestAllCondition(t *testing.T) {
+	start := time.Now()
+	_, endedBy, err := waitTest(t, All(
+		testCondition{name: "a", delay: 10 * time.Millisecond},
+		testCondition{name: "b", delay: 50 * time.Millisecond},
+	), time.Second)
+	if err != nil {
+		t.Fatalf("Wait() = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("All met after %v, before every condition is met", elapsed)
+	}
+	if endedBy != "all(a, b)" {
+		t.Errorf("endedBy = %q, want %q", endedBy, "all(a, b)")
+	}
+
+	nested := All(
+		Any(Delay(5*time.Millisecond), Delay(time.Hour)),
+		Any(Delay(5*time.Millisecond), Delay(time.Hour)),
+	)
+	_, endedBy, err = waitTest(t, nested, time.Second)
+	if err != nil {
+		t.Fatalf("Wait() of nested Any = %v", err)
+	}
+	if endedBy != nested.String() {
+		t.Errorf("endedBy = %q, want %q", endedBy, nested.String())
+	}
+
+	_, _, err = waitTest(t, All(
+		testCondition{name: "a", delay: 10 * time.Millisecond},
+		testCondition{name: "b", delay: time.Second},
+	), 50*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestNetworkIdleCondition(t *testing.T) {
+	_, endedBy, err := waitTest(t, Any(NetworkIdle(), Delay(time.Second)), time.Second)
+	if err != nil {
+		t.Fatalf("Wait() = %v", err)
+	}
+	if endedBy != WaitEndedByNetworkIdle {
+		t.Errorf("endedBy = %q, want %q", endedBy, WaitEndedByNetworkIdle)
+	}
+}
+
+func TestSinceLoadCondition(t *testing.T) {
+	r := NewRenderer()
+	state := r.newRenderState()
+	ctx := withWaitContext(context.Background(), waitContext{renderer: r, state: state})
+
+	time.AfterFunc(20*time.Millisecond, func() { state.lifecycle.fire("load") })
+
+	start := time.Now()
+	if err := SinceLoad(50 * time.Millisecond).Wait(ctx); err != nil {
+		t.Fatalf("Wait() = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Errorf("SinceLoad met after %v, want at least 70ms", elapsed)
+	}
+}
+
+func TestLifecycleCondition(t *testing.T) {
+	r := NewRenderer()
+	state := r.newRenderState()
+	endedBy := ""
+	ctx := withWaitContext(context.Background(), waitContext{
+		renderer: r,
+		state:    state,
+		endedBy:  &endedBy,
+	})
+
+	time.AfterFunc(20*time.Millisecond, func() {
+		state.lifecycle.fire("load")
+		state.lifecycle.fire("firstContentfulPaint")
+	})
+
+	condition := idleTypeCondition(BrowserConf{IdleType: "firstContentfulPaint"})
+	if err := Any(condition, Delay(time.Second)).Wait(ctx); err != nil {
+		t.Fatalf("Wait() = %v", err)
+	}
+	if endedBy != WaitEndedByFirstContentfulPaint {
+		t.Errorf("endedBy = %q, want %q", endedBy, WaitEndedByFirstContentfulPaint)
+	}
+}
+
+func TestDOMQuietCondition(t *testing.T) {
+	condition := idleTypeCondition(BrowserConf{IdleType: "domQuiet", DOMQuietFrames: -1})
+	want := domQuietCondition{window: defaultDOMQuietWindow, frames: 0}
+	if condition != want {
+		t.Errorf("idleTypeCondition() = %+v, want %+v", condition, want)
+	}
+	if condition.String() != WaitEndedByDOMQuiet {
+		t.Errorf("String() = %q, want %q", condition.String(), WaitEndedByDOMQuiet)
+	}
+
+	expression := domQuietExpression(300*time.Millisecond, 2)
+	for _, part := range []string{"< 300)", "let frames = 2;"} {
+		if !strings.Contains(expression, part) {
+			t.Errorf("domQuietExpression() missing %q: %s", part, expression)
+		}
+	}
+}
+
+func TestConditionOutsideRender(t *testing.T) {
+	if err := NetworkIdle().Wait(context.Background()); !errors.Is(err, errNoRenderState) {
+		t.Errorf("Wait() = %v, want %v", err, errNoRenderState)
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	l := newLifecycle()
+
+	waiting := l.fired("load")
+	l.fire("DOMContentLoaded")
+	l.reset()
+	l.fire("load")
+
+	select {
+	case <-waiting:
+	default:
+		t.Fatal("waiter of load not woken after reset")
+	}
+	if l.firedAt("DOMContentLoaded") != (time.Time{}) {
+		t.Error("DOMContentLoaded still recorded after reset")
+	}
+
+	select {
+	case <-l.fired("DOMContentLoaded"):
+		t.Error("DOMContentLoaded fired after reset")
+	default:
+	}
+}